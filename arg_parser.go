@@ -11,9 +11,18 @@ const (
 	ArgTypeShort ArgType = iota
 	ArgTypeLong
 	ArgTypeValue
+	// ArgTypeTerminator is the `--` ending all options. Everything after it is a value.
+	ArgTypeTerminator
 )
 
+// Terminator ends option parsing. All following arguments are taken as values.
+const Terminator = "--"
+
 func NewArgType(arg string) ArgType {
+	if arg == Terminator {
+		return ArgTypeTerminator
+	}
+
 	if !strings.HasPrefix(arg, "-") {
 		return ArgTypeValue
 	}
@@ -36,51 +45,86 @@ func (t ArgType) Value(from string) string {
 	}
 }
 
+// Prefix returns the dashes introducing a flag of this type.
+func (t ArgType) Prefix() string {
+	switch t {
+	case ArgTypeShort:
+		return "-"
+	case ArgTypeLong:
+		return "--"
+	default:
+		return ""
+	}
+}
+
 type ArgValue struct {
 	Type     ArgType
 	Value    string
 	Consumed bool
+	// Assigned values are bound to the preceding flag within the same raw argument (iE `--foo=bar` or `-p8080`).
+	Assigned bool
+	// RawIndex is the index of the raw argument the value originates from or -1 if unknown.
+	RawIndex int
+}
+
+// String returns the argument as given on the command line (iE `--foo`).
+func (a ArgValue) String() string {
+	return a.Type.Prefix() + a.Value
 }
 
 type ArgParserExt struct {
 	Args []ArgValue
+	// positionals makes the values before the first flag trailing as well (iE the positional arguments following a
+	// subcommand name).
+	positionals bool
 }
 
 func NewArgParserExt(args []string) *ArgParserExt {
-	sanitized := NewDefaultArgumentSanitizer(args)
+	return NewArgParserExtFrom(NewDefaultArgumentSanitizer(args), nil)
+}
+
+// NewArgParserExtFrom creates an ArgParserExt from the arguments of the given sanitizer.
+// With a schema given, arguments are classified with knowledge about the flags (iE negative numbers as values).
+func NewArgParserExtFrom(sanitizer *ArgumentSanitizer, schema *Schema) *ArgParserExt {
+	tokens := sanitizer.Tokens()
 	ext := &ArgParserExt{
-		Args: make([]ArgValue, 0, len(sanitized.Get())),
+		Args: make([]ArgValue, 0, len(tokens)),
 	}
-	for _, arg := range sanitized.Get() {
-		argType := NewArgType(arg)
-		value := argType.Value(arg)
+	classifier := NewArgClassifier(schema)
+	for _, token := range tokens {
+		argType := classifier.ClassifyToken(token)
 		ext.Args = append(ext.Args, ArgValue{
 			Type:     argType,
-			Value:    value,
+			Value:    argType.Value(token.Arg),
 			Consumed: false,
+			Assigned: token.Assigned,
+			RawIndex: token.Index,
 		})
 	}
 	return ext
 }
 
+// shiftRawIndices adds `offset` to the known raw indices of all arguments, as the raw arguments of the parser started
+// at `offset` within the whole command line.
+func (ext *ArgParserExt) shiftRawIndices(offset int) *ArgParserExt {
+	for index := range ext.Args {
+		if ext.Args[index].RawIndex >= 0 {
+			ext.Args[index].RawIndex += offset
+		}
+	}
+	return ext
+}
+
 // findAll finds all arguments matching the given key and type.
 // if the key occurs multiple times, all values are returned (iE -a 1 -a 2 -> a=[1,2]).
 func (ext *ArgParserExt) findAll(key string, argType ArgType) (args []*ArgValue, ok bool) {
 	args = make([]*ArgValue, 0)
-	ok = false
-
-	for indexArg, arg := range ext.Args {
-		if arg.Type == argType && arg.Value == key {
-			ok = true
-			// Get all values for this key if there are any.
-			for index := indexArg + 1; index < len(ext.Args) && ext.Args[index].Type == ArgTypeValue; index++ {
-				args = append(args, &ext.Args[index])
-				indexArg++
-			}
-		}
+	occurrences := ext.occurrences(flagRef{key: key, argType: argType})
+	for _, found := range occurrences {
+		args = append(args, found.values...)
 	}
 
-	return args, ok
+	return args, len(occurrences) > 0
 }
 
 func (ext *ArgParserExt) Get(key string, argType ArgType) (values []string, ok bool) {
@@ -97,7 +141,63 @@ func (ext *ArgParserExt) Get(key string, argType ArgType) (values []string, ok b
 	return values, true
 }
 
+// Count returns the number of occurrences of the flag.
+func (ext *ArgParserExt) Count(key string, argType ArgType) int {
+	count := 0
+	for _, arg := range ext.Args {
+		if arg.Type == argType && arg.Value == key {
+			count++
+		}
+	}
+	return count
+}
+
+// flagRef names a single flag on the command line.
+type flagRef struct {
+	key     string
+	argType ArgType
+}
+
+// occurrence is a single occurrence of a flag on the command line with all values following it.
+type occurrence struct {
+	flag   *ArgValue
+	values []*ArgValue
+}
+
+// consume marks the flag and its first `n` values as consumed.
+func (o occurrence) consume(n int) {
+	o.flag.Consumed = true
+	for _, value := range o.values[:min(n, len(o.values))] {
+		value.Consumed = true
+	}
+}
+
+// occurrences returns all occurrences of any of the given flags in the order of the command line.
+func (ext *ArgParserExt) occurrences(refs ...flagRef) []occurrence {
+	result := make([]occurrence, 0)
+	for indexArg := 0; indexArg < len(ext.Args); indexArg++ {
+		arg := &ext.Args[indexArg]
+		if !slices.Contains(refs, flagRef{key: arg.Value, argType: arg.Type}) {
+			continue
+		}
+		found := occurrence{flag: arg, values: make([]*ArgValue, 0)}
+		// Get all values for this flag if there are any.
+		for index := indexArg + 1; index < len(ext.Args) && ext.Args[index].Type == ArgTypeValue; index++ {
+			found.values = append(found.values, &ext.Args[index])
+		}
+		result = append(result, found)
+	}
+	return result
+}
+
+// Consume marks all occurrences of the flag and its first `n` values as consumed.
 func (ext *ArgParserExt) Consume(key string, argType ArgType, n int) *ArgParserExt {
+	for index := range ext.Args {
+		if ext.Args[index].Type == argType && ext.Args[index].Value == key {
+			ext.Args[index].Consumed = true
+		}
+	}
+
 	args, ok := ext.findAll(key, argType)
 	if !ok {
 		return ext
@@ -111,30 +211,72 @@ func (ext *ArgParserExt) Consume(key string, argType ArgType, n int) *ArgParserE
 	return ext
 }
 
-func (ext *ArgParserExt) GetTrailing() []string {
+// UnconsumedFlags returns all distinct flags which have not been consumed, as given on the command line.
+func (ext *ArgParserExt) UnconsumedFlags() []string {
 	result := make([]string, 0)
+	for _, arg := range ext.Args {
+		if arg.Type == ArgTypeValue || arg.Type == ArgTypeTerminator || arg.Consumed {
+			continue
+		}
+		if flag := arg.String(); !slices.Contains(result, flag) {
+			result = append(result, flag)
+		}
+	}
+	return result
+}
+
+// trailingIndices returns the indices of all unconsumed values after the last flag, preceded by the unconsumed values
+// before the first flag if `positionals` is set.
+// A terminator in between is skipped, so values before and after it are trailing.
+func (ext *ArgParserExt) trailingIndices() []int {
+	result := make([]int, 0)
 
 	for i := len(ext.Args) - 1; i >= 0; i-- {
+		if ext.Args[i].Type == ArgTypeTerminator {
+			continue
+		}
 		if ext.Args[i].Type != ArgTypeValue {
 			break
 		}
 		if ext.Args[i].Consumed {
 			break
 		}
-		result = append(result, ext.Args[i].Value)
+		result = append(result, i)
 	}
 
 	slices.Reverse(result)
+
+	if !ext.positionals {
+		return result
+	}
+	end := len(ext.Args)
+	if len(result) > 0 {
+		end = result[0]
+	}
+	leading := make([]int, 0)
+	for i := 0; i < end && ext.Args[i].Type == ArgTypeValue; i++ {
+		if !ext.Args[i].Consumed {
+			leading = append(leading, i)
+		}
+	}
+	return append(leading, result...)
+}
+
+func (ext *ArgParserExt) GetTrailing() []string {
+	result := make([]string, 0)
+	for _, index := range ext.trailingIndices() {
+		result = append(result, ext.Args[index].Value)
+	}
 	return result
 }
 
 func (ext *ArgParserExt) ConsumeTrailing(n int) *ArgParserExt {
-	trailing := ext.GetTrailing()
+	trailing := ext.trailingIndices()
 	if n > len(trailing) {
 		n = len(trailing)
 	}
-	for i := range n {
-		ext.Args[len(ext.Args)-len(trailing)+i].Consumed = true
+	for _, index := range trailing[:n] {
+		ext.Args[index].Consumed = true
 	}
 	return ext
 }
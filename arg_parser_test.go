@@ -104,3 +104,38 @@ func TestConsumeAllTrailing(t *testing.T) {
 	parser.ConsumeTrailing(3)
 	assert.Equal(t, []string{}, parser.GetTrailing())
 }
+
+func TestUnconsumedFlags(t *testing.T) {
+	parser := NewArgParserExt([]string{"-d", "hello", "--other", "foo", "-x", "--other"})
+	parser.Consume("d", ArgTypeShort, 1)
+	assert.Equal(t, []string{"--other", "-x"}, parser.UnconsumedFlags())
+
+	parser.Consume("other", ArgTypeLong, 0)
+	assert.Equal(t, []string{"-x"}, parser.UnconsumedFlags())
+}
+
+func TestTerminator(t *testing.T) {
+	parser := NewArgParserExt([]string{"-d", "hello", "--", "-x", "--other", "world"})
+
+	got, ok := parser.Get("d", ArgTypeShort)
+	require.True(t, ok)
+	assert.Equal(t, []string{"hello"}, got)
+
+	_, ok = parser.Get("other", ArgTypeLong)
+	assert.False(t, ok)
+
+	parser.Consume("d", ArgTypeShort, 1)
+	assert.Equal(t, []string{"-x", "--other", "world"}, parser.GetTrailing())
+	assert.Empty(t, parser.UnconsumedFlags())
+
+	parser.ConsumeTrailing(1)
+	assert.Equal(t, []string{"--other", "world"}, parser.GetTrailing())
+}
+
+func TestTerminatorKeepsTrailingBeforeIt(t *testing.T) {
+	parser := NewArgParserExt([]string{"-d", "hello", "--", "world"})
+	assert.Equal(t, []string{"hello", "world"}, parser.GetTrailing())
+
+	parser.ConsumeTrailing(1)
+	assert.Equal(t, []string{"world"}, parser.GetTrailing())
+}
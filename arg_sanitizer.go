@@ -5,80 +5,187 @@ import "strings"
 // SanitizerFn is a function that sanitizes a slice of strings.
 type SanitizerFn = func([]string) []string
 
+// TokenSanitizerFn is a function that sanitizes a slice of tokens.
+type TokenSanitizerFn = func([]Token) []Token
+
+// Token is a sanitized argument, which remembers the raw argument it originates from.
+type Token struct {
+	Arg string
+	// Index of the raw argument the token originates from or -1 if unknown.
+	Index int
+	// Assigned tokens are values bound to the preceding flag within the same raw argument (iE `bar` of `--foo=bar`
+	// or `8080` of `-p8080`).
+	Assigned bool
+}
+
+// NewTokens returns a token for each of the raw arguments.
+func NewTokens(args []string) []Token {
+	tokens := make([]Token, 0, len(args))
+	for index, arg := range args {
+		tokens = append(tokens, Token{Arg: arg, Index: index})
+	}
+	return tokens
+}
+
+// tokenArgs returns the arguments of the tokens.
+func tokenArgs(tokens []Token) []string {
+	args := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		args = append(args, token.Arg)
+	}
+	return args
+}
+
 // ArgumentSanitizer sanitizes a slice of strings `.With()` given sanitizer functions applied on `.Get()`.
 type ArgumentSanitizer struct {
-	sanitizers []SanitizerFn
-	args       []string
+	sanitizers []TokenSanitizerFn
+	tokens     []Token
 }
 
 // NewDefaultArgumentSanitizer returns a new ArgumentSanitizer without any actual sanitizers.
 func NewArgumentSanitizer(args []string) *ArgumentSanitizer {
 	return &ArgumentSanitizer{
-		sanitizers: make([]SanitizerFn, 0),
-		args:       args,
+		sanitizers: make([]TokenSanitizerFn, 0),
+		tokens:     NewTokens(args),
 	}
 }
 
 // With adds a sanitizer function to the ArgumentSanitizer.
+// As plain strings can not be traced back to the raw arguments, all tokens lose their origin.
 func (s *ArgumentSanitizer) With(fn SanitizerFn) *ArgumentSanitizer {
+	return s.WithTokens(func(tokens []Token) []Token {
+		sanitized := NewTokens(fn(tokenArgs(tokens)))
+		for index := range sanitized {
+			sanitized[index].Index = -1
+		}
+		return sanitized
+	})
+}
+
+// WithTokens adds a sanitizer function working on tokens to the ArgumentSanitizer.
+func (s *ArgumentSanitizer) WithTokens(fn TokenSanitizerFn) *ArgumentSanitizer {
 	s.sanitizers = append(s.sanitizers, fn)
 	return s
 }
 
 // ExplodeShortsSanitizer retruns a sanitizer with all sanitizers enabled.
 func NewDefaultArgumentSanitizer(args []string) *ArgumentSanitizer {
+	return NewSchemaArgumentSanitizer(args, nil)
+}
+
+// NewSchemaArgumentSanitizer returns a sanitizer with all sanitizers enabled, which knows about the flags of `schema`.
+func NewSchemaArgumentSanitizer(args []string, schema *Schema) *ArgumentSanitizer {
+	return NewArgumentSanitizer(args).
+		WithTokens(skipLeadingValues).
+		WithTokens(splitAssignments(schema)).
+		WithTokens(explodeShorts(schema))
+}
+
+// NewSubcommandArgumentSanitizer returns a sanitizer for the arguments following a subcommand name.
+// Leading values are kept as they are the subcommand's positional arguments.
+func NewSubcommandArgumentSanitizer(args []string, schema *Schema) *ArgumentSanitizer {
 	return NewArgumentSanitizer(args).
-		With(SanitizerSkipLeadingValues).
-		With(SanitizeSplitAssignmets).
-		With(SanitizeExplodeShorts)
+		WithTokens(splitAssignments(schema)).
+		WithTokens(explodeShorts(schema))
 }
 
 // Get returns the sanitized arguments after applying all sanitizers.
 func (s *ArgumentSanitizer) Get() []string {
+	return tokenArgs(s.Tokens())
+}
+
+// Tokens returns the sanitized tokens after applying all sanitizers.
+func (s *ArgumentSanitizer) Tokens() []Token {
 	for _, fn := range s.sanitizers {
-		s.args = fn(s.args)
+		s.tokens = fn(s.tokens)
 	}
-	return s.args
+	s.sanitizers = s.sanitizers[:0]
+	return s.tokens
 }
 
-// SanitizeSplitAssignmets splits an argument into its key and value if present (iE --foo=bar -> --foo bar).
+// SanitizeSplitAssignmets splits a flag into its key and value if present (iE --foo=bar -> --foo bar).
+// Values like `key=value` are kept as they are.
 func SanitizeSplitAssignmets(args []string) []string {
-	result := make([]string, 0)
-	for _, arg := range args {
-		parts := strings.SplitN(arg, "=", 2)
-		for _, part := range parts {
-			result = append(result, part)
+	return tokenArgs(splitAssignments(nil)(NewTokens(args)))
+}
+
+// splitAssignments splits flags into the flag and its assigned value (iE `--foo=bar` -> `--foo` and `bar`).
+// Values and flags without a name (iE `--=bar`, which would become the terminator) are never split. With a schema
+// given, a short flag with an attached value keeps the `=` in its value (iE `-Dkey=value` -> `-D` and `key=value` after
+// exploding).
+func splitAssignments(schema *Schema) TokenSanitizerFn {
+	return func(tokens []Token) []Token {
+		result := make([]Token, 0, len(tokens))
+		for index, token := range tokens {
+			if token.Arg == Terminator && !token.Assigned {
+				return append(result, tokens[index:]...)
+			}
+			argType := NewArgType(token.Arg)
+			flag, value, isAssignment := strings.Cut(token.Arg, "=")
+			if token.Assigned || argType == ArgTypeValue || !isAssignment || argType.Value(flag) == "" {
+				result = append(result, token)
+				continue
+			}
+			if argType == ArgTypeShort && schema != nil {
+				if _, attached := splitShorts(schema, flag); attached != "" {
+					result = append(result, token)
+					continue
+				}
+			}
+			result = append(result,
+				Token{Arg: flag, Index: token.Index},
+				Token{Arg: value, Index: token.Index, Assigned: true},
+			)
 		}
+		return result
 	}
-	return result
 }
 
 // SanitizerSkipLeadingValues removes prefixed values that can not be assigned to any argument.
 func SanitizerSkipLeadingValues(args []string) []string {
-	for index, arg := range args {
-		argType := NewArgType(arg)
+	return tokenArgs(skipLeadingValues(NewTokens(args)))
+}
 
-		if argType != ArgTypeValue {
-			return args[index:]
+func skipLeadingValues(tokens []Token) []Token {
+	for index, token := range tokens {
+		if NewArgType(token.Arg) != ArgTypeValue {
+			return tokens[index:]
 		}
 	}
-	return make([]string, 0)
+	return make([]Token, 0)
 }
 
 // SanitizeExplodeShorts splits combined short flags into separate arguments (iE -abc -> -a -b -c).
 func SanitizeExplodeShorts(args []string) []string {
-	sanitized := make([]string, 0)
-	for _, arg := range args {
-		argType := NewArgType(arg)
+	return tokenArgs(explodeShorts(nil)(NewTokens(args)))
+}
 
-		if argType == ArgTypeShort && len(arg) > 2 {
-			for _, c := range arg[1:] {
-				sanitized = append(sanitized, "-"+string(c))
+// explodeShorts splits combined short flags into separate tokens. With a schema given, arguments the schema takes as
+// values are kept (iE `-35` after a numeric flag). Only runs of flags without values get exploded, the rest of the
+// argument after a flag taking a value is its value (iE `-vp8080` -> -v -p 8080).
+func explodeShorts(schema *Schema) TokenSanitizerFn {
+	return func(tokens []Token) []Token {
+		sanitized := make([]Token, 0, len(tokens))
+		classifier := NewArgClassifier(schema)
+		for index, token := range tokens {
+			argType := classifier.ClassifyToken(token)
+			if argType == ArgTypeTerminator {
+				return append(sanitized, tokens[index:]...)
+			}
+
+			if argType == ArgTypeShort && len(token.Arg) > 2 {
+				flags, value := splitShorts(schema, token.Arg)
+				for _, flag := range flags {
+					sanitized = append(sanitized, Token{Arg: flag, Index: token.Index})
+				}
+				if value != "" {
+					sanitized = append(sanitized, Token{Arg: value, Index: token.Index, Assigned: true})
+				}
+				continue
 			}
-			continue
-		}
 
-		sanitized = append(sanitized, arg)
+			sanitized = append(sanitized, token)
+		}
+		return sanitized
 	}
-	return sanitized
 }
@@ -1,9 +1,11 @@
 package clapper
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDefaultSanitizeArgs(t *testing.T) {
@@ -37,6 +39,11 @@ func TestDefaultSanitizeArgs(t *testing.T) {
 			input:    []string{"-d=hello", "--some"},
 			expected: []string{"-d", "hello", "--some"},
 		},
+		{
+			name:     "terminator stops sanitizing",
+			input:    []string{"-ab", "--", "-cd", "--e=f"},
+			expected: []string{"-a", "-b", "--", "-cd", "--e=f"},
+		},
 		{
 			name:     "trailing values",
 			input:    []string{"-d", "foo", "bar"},
@@ -50,3 +57,142 @@ func TestDefaultSanitizeArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestSchemaSanitizeArgs(t *testing.T) {
+	type Foo struct {
+		Port    int    `clapper:"short=p"`
+		Output  string `clapper:"short=o"`
+		Verbose bool   `clapper:"short=v"`
+		Offset  int    `clapper:"long"`
+		Color   bool   `clapper:"short=c,long"`
+	}
+
+	typ := reflect.TypeOf(Foo{})
+	tags, err := parseStructTags(typ, conversion{})
+	require.NoError(t, err)
+	schema := NewSchema(typ, tags)
+
+	tests := []struct {
+		name     string
+		input    []string
+		expected []string
+	}{
+		{
+			name:     "attached value",
+			input:    []string{"-p8080"},
+			expected: []string{"-p", "8080"},
+		},
+		{
+			name:     "bool run before attached value",
+			input:    []string{"-vofile.txt"},
+			expected: []string{"-v", "-o", "file.txt"},
+		},
+		{
+			name:     "unknown flags are exploded",
+			input:    []string{"-xyz"},
+			expected: []string{"-x", "-y", "-z"},
+		},
+		{
+			name:     "flag taking value without attached value",
+			input:    []string{"-vp", "80"},
+			expected: []string{"-v", "-p", "80"},
+		},
+		{
+			name:     "negative number is kept",
+			input:    []string{"--offset", "-35"},
+			expected: []string{"--offset", "-35"},
+		},
+		{
+			name:     "assignments to flags are split",
+			input:    []string{"--color=false", "--offset=3"},
+			expected: []string{"--color", "false", "--offset", "3"},
+		},
+		{
+			name:     "values with assignments are kept",
+			input:    []string{"--offset", "3", "a=b", "--", "-c=d"},
+			expected: []string{"--offset", "3", "a=b", "--", "-c=d"},
+		},
+		{
+			name:     "assignment to combined bool shorts",
+			input:    []string{"-vc=no"},
+			expected: []string{"-v", "-c", "no"},
+		},
+		{
+			name:     "attached value keeps its assignment",
+			input:    []string{"-oa=b"},
+			expected: []string{"-o", "a=b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewSchemaArgumentSanitizer(tt.input, schema).Get()
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestSanitizeTokens(t *testing.T) {
+	type Foo struct {
+		Port    int    `clapper:"short=p,long"`
+		Verbose bool   `clapper:"short=v"`
+		Define  string `clapper:"short=D"`
+	}
+
+	typ := reflect.TypeOf(Foo{})
+	tags, err := parseStructTags(typ, conversion{})
+	require.NoError(t, err)
+	schema := NewSchema(typ, tags)
+
+	tests := []struct {
+		name     string
+		input    []string
+		expected []Token
+	}{
+		{
+			name:  "leading values keep their index",
+			input: []string{"foo", "--port=80", "bar"},
+			expected: []Token{
+				{Arg: "--port", Index: 1},
+				{Arg: "80", Index: 1, Assigned: true},
+				{Arg: "bar", Index: 2},
+			},
+		},
+		{
+			name:  "exploded shorts share their index",
+			input: []string{"-v", "-vp8080", "-Dkey=value"},
+			expected: []Token{
+				{Arg: "-v", Index: 0},
+				{Arg: "-v", Index: 1},
+				{Arg: "-p", Index: 1},
+				{Arg: "8080", Index: 1, Assigned: true},
+				{Arg: "-D", Index: 2},
+				{Arg: "key=value", Index: 2, Assigned: true},
+			},
+		},
+		{
+			name:  "assigned values are never exploded",
+			input: []string{"-p=-5", "--port=--"},
+			expected: []Token{
+				{Arg: "-p", Index: 0},
+				{Arg: "-5", Index: 0, Assigned: true},
+				{Arg: "--port", Index: 1},
+				{Arg: "--", Index: 1, Assigned: true},
+			},
+		},
+		{
+			name:  "flags without name are never split",
+			input: []string{"--=x", "-p=5"},
+			expected: []Token{
+				{Arg: "--=x", Index: 0},
+				{Arg: "-p", Index: 1},
+				{Arg: "5", Index: 1, Assigned: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewSchemaArgumentSanitizer(tt.input, schema).Tokens()
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -2,8 +2,8 @@ package clapper
 
 import (
 	"fmt"
-	"os"
 	"reflect"
+	"strings"
 )
 
 func mustTagTypeToArgType(tagType TagType) ArgType {
@@ -17,7 +17,7 @@ func mustTagTypeToArgType(tagType TagType) ArgType {
 	}
 }
 
-func valuesFor(tagType TagType, tags TagMap, args *ArgParserExt) (key string, values []string) {
+func valuesFor(tagType TagType, tags TagMap, args *ArgParserExt) (key string, values []*ArgValue) {
 	tag, ok := tags[tagType]
 	if !ok {
 		return "", nil
@@ -26,7 +26,7 @@ func valuesFor(tagType TagType, tags TagMap, args *ArgParserExt) (key string, va
 
 	key = tag.ArgumentName()
 
-	values, ok = args.Get(key, argType)
+	values, ok = args.findAll(key, argType)
 	if !ok {
 		return key, nil
 	}
@@ -36,33 +36,180 @@ func valuesFor(tagType TagType, tags TagMap, args *ArgParserExt) (key string, va
 // Parse tries to evaluate the given `rawArgs` towards the provided struct `target` (which must include `clapper`-Tags).
 // If no `rawArgs` were provided, it defaults to `os.Args[1:]` (all command line arguments without the programm name).
 func Parse[T any](target *T, rawArgs ...string) (trailing []string, err error) {
-	t := reflect.TypeOf(*target)
-	if t.Kind() != reflect.Struct {
-		return nil, ErrNoStruct
+	return NewParser().Parse(target, rawArgs...)
+}
+
+// ParseCommand works like `Parse()` but additionally reports which subcommands have been selected.
+func ParseCommand[T any](target *T, rawArgs ...string) (*ParseResult, error) {
+	return NewParser().ParseCommand(target, rawArgs...)
+}
+
+// Parser evaluates command line arguments with additional options. Use `NewParser()` to create one.
+type Parser struct {
+	autoEnv   bool
+	envPrefix string
+	strict    bool
+	repeat    RepeatPolicy
+	literals  bool
+	// converters are only used by this parser, see `WithConverter()`.
+	converters map[reflect.Type]*Converter
+}
+
+// RepeatPolicy decides which value a non-slice flag takes if it is given several times.
+type RepeatPolicy int
+
+const (
+	// RepeatFirstWins takes the value of the first occurrence. The long flag takes precedence over the short one.
+	RepeatFirstWins RepeatPolicy = iota
+	// RepeatLastWins takes the value of the last occurrence, like most unix tools do (iE `--color=auto --color=never`).
+	RepeatLastWins
+	// RepeatError fails parsing with a RepeatedFlagError.
+	RepeatError
+)
+
+// fieldOptions are the parser's options affecting how a single field is set.
+type fieldOptions struct {
+	repeat RepeatPolicy
+	// literals accepts Go integer literals.
+	literals bool
+	// converters are the parser's own converters.
+	converters map[reflect.Type]*Converter
+}
+
+// conversion returns how values of a field with the given tags are converted.
+func (o fieldOptions) conversion(tags TagMap) conversion {
+	unit, units := tags.Units()
+	return conversion{
+		literals:   o.literals,
+		units:      units,
+		unit:       unit,
+		layout:     tags.Layout(),
+		converters: o.converters,
+	}
+}
+
+// NewParser returns a Parser without any options set, behaving like `Parse()`.
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// WithEnvPrefix lets every tagged field fall back to an environment variable derived from its name, prefixed by
+// `prefix` (iE `MYAPP` and `DbHost` -> `MYAPP_DB_HOST`). An empty prefix derives the names without any prefix.
+// Fields opt out with `env=-`, explicitly named variables (`env=SOME_NAME`) are taken as they are.
+// Fields of subcommands additionally get the subcommand names as prefix (iE `MYAPP_REMOTE_ADD_NAME`).
+func (p *Parser) WithEnvPrefix(prefix string) *Parser {
+	p.autoEnv = true
+	p.envPrefix = prefix
+	return p
+}
+
+// WithStrict makes parsing fail with an UnknownFlagError if any given flag is not known to the target struct,
+// instead of silently discarding it.
+func (p *Parser) WithStrict() *Parser {
+	p.strict = true
+	return p
+}
+
+// WithRepeatPolicy sets how non-slice flags given several times are handled. Defaults to RepeatFirstWins.
+func (p *Parser) WithRepeatPolicy(policy RepeatPolicy) *Parser {
+	p.repeat = policy
+	return p
+}
+
+// WithNumericLiterals makes integer values accept the syntax of Go integer literals: prefixes for other bases
+// (`0x1F`, `0o755`, `0b1010`) and underscores separating digits (`1_000_000`). As in Go, a leading zero makes a number
+// octal (`0755`).
+func (p *Parser) WithNumericLiterals() *Parser {
+	p.literals = true
+	return p
+}
+
+// fieldOptions returns the parser's options for setting fields.
+func (p *Parser) fieldOptions() fieldOptions {
+	return fieldOptions{repeat: p.repeat, literals: p.literals, converters: p.converters}
+}
+
+// conversion returns the parser's conversion of values without any field specific tags.
+func (p *Parser) conversion() conversion {
+	return p.fieldOptions().conversion(nil)
+}
+
+// structTags parses the tags of `t` and applies the parser's options to them.
+// `path` holds the names of the subcommands leading to `t`.
+func (p *Parser) structTags(t reflect.Type, path []string) (ParsedTags, error) {
+	parsedTags, err := parseStructTags(t, p.conversion())
+	if err != nil {
+		return nil, err
 	}
 
-	if len(rawArgs) == 0 {
-		rawArgs = os.Args[1:] // skip the first argument (program name)
+	prefix := p.envPrefixFor(path)
+	for index, tags := range parsedTags {
+		parsedTags[index] = p.resolveEnv(t.Field(index).Name, index, prefix, tags)
 	}
 
-	args := NewArgParserExt(rawArgs)
+	return parsedTags, nil
+}
 
-	parsedTags, err := parseStructTags(t)
+// envPrefixFor returns the prefix for derived environment variable names of the command at `path`.
+// Without an env prefix set, names are never prefixed, not even by the subcommand names.
+func (p *Parser) envPrefixFor(path []string) string {
+	if !p.autoEnv {
+		return ""
+	}
+	parts := make([]string, 0, len(path)+1)
+	if p.envPrefix != "" {
+		parts = append(parts, p.envPrefix)
+	}
+	for _, name := range path {
+		parts = append(parts, deriveEnvName(name))
+	}
+	return strings.Join(parts, "_")
+}
+
+// resolveEnv adds, prefixes or removes the env tag of a single field according to the parser's options.
+func (p *Parser) resolveEnv(fieldName string, index int, prefix string, tags TagMap) TagMap {
+	tag, ok := tags[TagEnv]
+	switch {
+	case ok && tag.Value == envOptOut:
+		delete(tags, TagEnv)
+		return tags
+	case ok && tag.HasValue():
+		return tags
+	case !ok && (!p.autoEnv || !tags.HasInputTag()):
+		return tags
+	case !ok:
+		tag = Tag{Type: TagEnv, Name: deriveEnvName(fieldName), Index: index}
+	}
+
+	if prefix != "" {
+		tag.Name = prefix + "_" + tag.Name
+	}
+	tags[TagEnv] = tag
+
+	return tags
+}
+
+// Parse works like the package level `Parse()` but respects the parser's options. `target` must be a pointer to a struct.
+func (p *Parser) Parse(target any, rawArgs ...string) (trailing []string, err error) {
+	result, err := p.ParseCommand(target, rawArgs...)
 	if err != nil {
 		return nil, err
 	}
+	return result.Trailing, nil
+}
 
-	reflectValue := reflect.ValueOf(target).Elem()
-	processor := NewStructFieldProcessor(t, reflectValue, parsedTags, args)
-	for !processor.EOF() {
-		if err = processor.Next(); err != nil {
-			return nil, err
-		}
+// ParseCommand works like the package level `ParseCommand()` but respects the parser's options.
+// `target` must be a pointer to a struct.
+func (p *Parser) ParseCommand(target any, rawArgs ...string) (*ParseResult, error) {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return nil, ErrNoStruct
 	}
 
-	if err = processor.Finalize(); err != nil {
+	result, err := p.parse(value.Elem(), rawArgs)
+	if err != nil {
 		return nil, err
 	}
 
-	return processor.GetTrailing(), nil
+	return result, nil
 }
@@ -1,10 +1,19 @@
 package clapper
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -164,8 +173,8 @@ func TestLongOverrideOfShortFails(t *testing.T) {
 
 	var foo Foo
 	_, err := Parse(&foo, "-n", "1")
-	require.ErrorIs(t, err, NewParseError(
-		ErrShortOverrideCanOnlyBeOneLetter, 0, "ShortAndLong", "short=nope",
+	require.ErrorIs(t, err, NewParseErrorAt(
+		ErrShortOverrideCanOnlyBeOneLetter, 0, "ShortAndLong", "short=nope", 1,
 	))
 }
 
@@ -176,8 +185,8 @@ func TestShortOverrideOfLongFails(t *testing.T) {
 
 	var foo Foo
 	_, err := Parse(&foo, "-s", "1")
-	assert.ErrorIs(t, err, NewParseError(
-		ErrLongMustBeMoreThanOne, 0, "ShortAndLong", "long=s",
+	assert.ErrorIs(t, err, NewParseErrorAt(
+		ErrLongMustBeMoreThanOne, 0, "ShortAndLong", "long=s", 1,
 	))
 }
 
@@ -217,7 +226,7 @@ func TestBoolWithValue(t *testing.T) {
 
 func TestStringDefaultStringMandatoryTrail(t *testing.T) {
 	type Foo struct {
-		Help    bool    `clapper:"short,long,help='Display help message"`
+		Help    bool    `clapper:"short,long,help='Display help message'"`
 		Version bool    `clapper:"short,long,help='Display version information'"`
 		Debug   bool    `clapper:"short,long,help='Enable debug mode'"`
 		Server  string  `clapper:"short,long,default='localhost:8080',help='Server to connect to'"`
@@ -519,3 +528,2173 @@ func TestMultipleCommandTagsFail(t *testing.T) {
 	_, err := Parse(&foo, "-F", "hello", "this remains trailing")
 	assert.ErrorIs(t, err, ErrDuplicateCommandTag)
 }
+
+func TestEnvFallback(t *testing.T) {
+	type Foo struct {
+		Host string `clapper:"long,env"`
+		Port int    `clapper:"long,env=SERVICE_PORT,default=80"`
+	}
+
+	t.Setenv("HOST", "example.com")
+	t.Setenv("SERVICE_PORT", "8080")
+
+	var foo Foo
+	_, err := Parse(&foo, "--nope")
+	require.NoError(t, err)
+
+	assert.Equal(t, "example.com", foo.Host)
+	assert.Equal(t, 8080, foo.Port)
+}
+
+func TestEnvPrecedence(t *testing.T) {
+	type Foo struct {
+		Host string `clapper:"long,env,default=localhost"`
+	}
+
+	var foo Foo
+	_, err := Parse(&foo, "--nope")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", foo.Host)
+
+	t.Setenv("HOST", "from-env")
+	_, err = Parse(&foo, "--nope")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", foo.Host)
+
+	_, err = Parse(&foo, "--host", "from-args")
+	require.NoError(t, err)
+	assert.Equal(t, "from-args", foo.Host)
+}
+
+func TestEnvBool(t *testing.T) {
+	type Foo struct {
+		Debug   bool  `clapper:"long,env,default=true"`
+		Verbose *bool `clapper:"long,env"`
+	}
+
+	t.Setenv("DEBUG", "false")
+	t.Setenv("VERBOSE", "1")
+
+	var foo Foo
+	_, err := Parse(&foo, "--nope")
+	require.NoError(t, err)
+	assert.False(t, foo.Debug)
+	require.NotNil(t, foo.Verbose)
+	assert.True(t, *foo.Verbose)
+
+	t.Setenv("VERBOSE", "no")
+	_, err = Parse(&foo, "--nope")
+	require.NoError(t, err)
+	require.NotNil(t, foo.Verbose)
+	assert.False(t, *foo.Verbose)
+
+	t.Setenv("DEBUG", "maybe")
+	_, err = Parse(&foo, "--nope")
+	assert.ErrorIs(t, err, NewUnexpectedInputFormatError("maybe", reflect.TypeOf(true)))
+}
+
+func TestEnvMalformedValue(t *testing.T) {
+	type Foo struct {
+		Port int `clapper:"long,env,default=80"`
+	}
+
+	t.Setenv("PORT", "eighty")
+
+	var foo Foo
+	_, err := Parse(&foo, "--nope")
+	assert.ErrorIs(t, err, NewUnexpectedInputFormatError("eighty", reflect.TypeOf(42)))
+}
+
+func TestEnvHelp(t *testing.T) {
+	type Foo struct {
+		DbHost string `clapper:"short,long,env,help='Database host'"`
+	}
+
+	var foo Foo
+	help, err := HelpDefault(&foo)
+	require.NoError(t, err)
+	assert.Contains(t, help, "-d, --db-host [$DB_HOST]")
+}
+
+func TestEnvPrefix(t *testing.T) {
+	type Foo struct {
+		DbHost   string `clapper:"long"`
+		Port     int    `clapper:"long,env=SERVICE_PORT"`
+		Password string `clapper:"long,env=-,default=secret"`
+		Debug    bool   `clapper:"short,env"`
+		Ignored  string
+	}
+
+	t.Setenv("MYAPP_DB_HOST", "db.example.com")
+	t.Setenv("SERVICE_PORT", "5432")
+	t.Setenv("MYAPP_PASSWORD", "leaked")
+	t.Setenv("MYAPP_DEBUG", "true")
+	t.Setenv("MYAPP_IGNORED", "nope")
+
+	var foo Foo
+	_, err := NewParser().WithEnvPrefix("MYAPP").Parse(&foo, "--nope")
+	require.NoError(t, err)
+
+	assert.Equal(t, "db.example.com", foo.DbHost)
+	assert.Equal(t, 5432, foo.Port)
+	assert.Equal(t, "secret", foo.Password)
+	assert.True(t, foo.Debug)
+	assert.Empty(t, foo.Ignored)
+}
+
+func TestEnvPrefixEmpty(t *testing.T) {
+	type Foo struct {
+		DbHost string `clapper:"long"`
+	}
+
+	t.Setenv("DB_HOST", "db.example.com")
+
+	var foo Foo
+	_, err := NewParser().WithEnvPrefix("").Parse(&foo, "--nope")
+	require.NoError(t, err)
+	assert.Equal(t, "db.example.com", foo.DbHost)
+
+	_, err = Parse(&foo, "--nope")
+	assert.ErrorIs(t, err, NewMandatoryParameterError("db-host"))
+}
+
+func TestEnvPrefixHelp(t *testing.T) {
+	type Foo struct {
+		DbHost   string `clapper:"long"`
+		Password string `clapper:"long,env=-"`
+	}
+
+	var foo Foo
+	help, err := NewParser().WithEnvPrefix("MYAPP").HelpDefault(&foo)
+	require.NoError(t, err)
+	assert.Contains(t, help, "--db-host [$MYAPP_DB_HOST]")
+	assert.NotContains(t, help, "MYAPP_PASSWORD")
+}
+
+func TestParserNoStruct(t *testing.T) {
+	foo := 42
+	_, err := NewParser().Parse(&foo)
+	assert.ErrorIs(t, err, ErrNoStruct)
+
+	_, err = NewParser().Parse(struct{}{})
+	assert.ErrorIs(t, err, ErrNoStruct)
+}
+
+type remoteAddCmd struct {
+	Name string `clapper:"long"`
+	URL  string `clapper:"short,long=url,default=https://example.com"`
+}
+
+type remoteRemoveCmd struct {
+	Force bool `clapper:"short,long"`
+}
+
+type remoteCmd struct {
+	Verbose bool             `clapper:"short=v,long"`
+	Add     *remoteAddCmd    `clapper:"subcommand,help=Add a remote"`
+	Remove  *remoteRemoveCmd `clapper:"subcommand=rm,help=Remove a remote"`
+}
+
+type statusCmd struct {
+	Short bool `clapper:"short,long"`
+}
+
+type gitCmd struct {
+	Dir    string     `clapper:"short=C,default=."`
+	Remote *remoteCmd `clapper:"subcommand,help=Manage remotes"`
+	Status *statusCmd `clapper:"subcommand"`
+}
+
+func TestSubcommandNested(t *testing.T) {
+	var git gitCmd
+	result, err := ParseCommand(&git, "-C", "repo", "remote", "-v", "add", "--name", "origin", "trailing")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"remote", "add"}, result.Path)
+	assert.Equal(t, []string{"trailing"}, result.Trailing)
+	assert.Equal(t, "repo", git.Dir)
+	require.NotNil(t, git.Remote)
+	assert.True(t, git.Remote.Verbose)
+	require.NotNil(t, git.Remote.Add)
+	assert.Equal(t, "origin", git.Remote.Add.Name)
+	assert.Equal(t, "https://example.com", git.Remote.Add.URL)
+	assert.Same(t, git.Remote.Add, result.Command)
+
+	assert.Nil(t, git.Remote.Remove)
+	assert.Nil(t, git.Status)
+}
+
+func TestSubcommandOverriddenName(t *testing.T) {
+	var git gitCmd
+	result, err := ParseCommand(&git, "remote", "rm", "-F")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"remote", "rm"}, result.Path)
+	require.NotNil(t, git.Remote.Remove)
+	assert.True(t, git.Remote.Remove.Force)
+	assert.Nil(t, git.Remote.Add)
+}
+
+func TestSubcommandNotSelected(t *testing.T) {
+	var git gitCmd
+	result, err := ParseCommand(&git, "-C", "status")
+	require.NoError(t, err)
+
+	assert.Empty(t, result.Path)
+	assert.Same(t, &git, result.Command)
+	assert.Equal(t, "status", git.Dir)
+	assert.Nil(t, git.Status)
+	assert.Nil(t, git.Remote)
+}
+
+func TestSubcommandFlagsStayOnTheirLevel(t *testing.T) {
+	var git gitCmd
+	_, err := ParseCommand(&git, "remote", "add", "-v", "--name", "origin")
+	require.NoError(t, err)
+
+	assert.False(t, git.Remote.Verbose)
+	assert.Equal(t, "origin", git.Remote.Add.Name)
+}
+
+func TestSubcommandMandatoryParameter(t *testing.T) {
+	var git gitCmd
+	_, err := ParseCommand(&git, "remote", "add")
+	assert.ErrorIs(t, err, NewMandatoryParameterError("name"))
+}
+
+func TestSubcommandInvalidDefinitions(t *testing.T) {
+	type NoPointer struct {
+		Sub statusCmd `clapper:"subcommand"`
+	}
+	_, err := Parse(&NoPointer{}, "sub")
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrSubcommandNoStructPointer, 0, "Sub", "subcommand", 1))
+
+	type WithFlag struct {
+		Sub *statusCmd `clapper:"subcommand,long"`
+	}
+	_, err = Parse(&WithFlag{}, "sub")
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrSubcommandWithInput, 0, "Sub", "subcommand,long", 1))
+
+	type Duplicate struct {
+		Sub     *statusCmd `clapper:"subcommand=status"`
+		Another *statusCmd `clapper:"subcommand=status"`
+	}
+	_, err = Parse(&Duplicate{}, "status")
+	assert.ErrorIs(t, err, ErrDuplicateSubcommand)
+
+	type WithCommand struct {
+		Sub     *statusCmd `clapper:"subcommand"`
+		Command string     `clapper:"command"`
+	}
+	_, err = Parse(&WithCommand{}, "sub")
+	assert.ErrorIs(t, err, ErrCommandWithSubcommands)
+}
+
+func TestSubcommandHelp(t *testing.T) {
+	var git gitCmd
+	help, err := HelpDefault(&git)
+	require.NoError(t, err)
+	assert.Contains(t, help, "Commands:\n")
+	assert.Contains(t, help, "remote  - Manage remotes")
+	assert.Contains(t, help, "status")
+
+	help, err = HelpCommand(&git, DefaultHelpFormatter, "remote")
+	require.NoError(t, err)
+	assert.Contains(t, help, "-v, --verbose")
+	assert.Contains(t, help, "rm   - Remove a remote")
+
+	help, err = HelpCommand(&git, DefaultHelpFormatter, "remote", "add")
+	require.NoError(t, err)
+	assert.Contains(t, help, "--name")
+	assert.NotContains(t, help, "Commands:")
+
+	_, err = HelpCommand(&git, DefaultHelpFormatter, "nope")
+	assert.EqualError(t, err, "unknown command 'nope'")
+
+	_, err = HelpCommand(&git, DefaultHelpFormatter, "remtoe")
+	var unknownErr UnknownCommandError
+	require.ErrorAs(t, err, &unknownErr)
+	assert.Equal(t, []string{"remote"}, unknownErr.Suggestions)
+}
+
+func TestSubcommandEnvPrefix(t *testing.T) {
+	t.Setenv("GIT_REMOTE_ADD_NAME", "upstream")
+
+	var git gitCmd
+	_, err := NewParser().WithEnvPrefix("GIT").ParseCommand(&git, "remote", "add")
+	require.NoError(t, err)
+	assert.Equal(t, "upstream", git.Remote.Add.Name)
+}
+
+func TestSubcommandEnvWithoutPrefix(t *testing.T) {
+	type Add struct {
+		Name string `clapper:"long,env"`
+	}
+	type Remote struct {
+		Add *Add `clapper:"subcommand"`
+	}
+	type Git struct {
+		Remote *Remote `clapper:"subcommand"`
+	}
+	t.Setenv("NAME", "origin")
+	t.Setenv("REMOTE_ADD_NAME", "upstream")
+
+	var git Git
+	_, err := ParseCommand(&git, "remote", "add")
+	require.NoError(t, err)
+	assert.Equal(t, "origin", git.Remote.Add.Name)
+}
+
+type persistentLeaf struct {
+	Name string `clapper:"long,default=none"`
+}
+
+type persistentMiddle struct {
+	Force bool            `clapper:"short=f,long,persistent"`
+	Leaf  *persistentLeaf `clapper:"subcommand"`
+}
+
+type persistentRoot struct {
+	Verbose bool              `clapper:"short=v,long,persistent,help=Verbose output"`
+	Context string            `clapper:"long,persistent,default=default"`
+	Local   bool              `clapper:"long"`
+	Middle  *persistentMiddle `clapper:"subcommand"`
+}
+
+func TestPersistentFlagsAnyPosition(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []string
+	}{
+		{name: "before subcommands", input: []string{"-v", "--context", "prod", "middle", "leaf"}},
+		{name: "between subcommands", input: []string{"middle", "--context", "prod", "-v", "leaf"}},
+		{name: "after subcommands", input: []string{"middle", "leaf", "--context=prod", "-v"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var root persistentRoot
+			result, err := ParseCommand(&root, tt.input...)
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"middle", "leaf"}, result.Path)
+			assert.True(t, root.Verbose)
+			assert.Equal(t, "prod", root.Context)
+			assert.Equal(t, "none", root.Middle.Leaf.Name)
+		})
+	}
+}
+
+func TestPersistentFlagValueIsNoSubcommand(t *testing.T) {
+	var root persistentRoot
+	result, err := ParseCommand(&root, "middle", "--context", "leaf")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"middle"}, result.Path)
+	assert.Equal(t, "leaf", root.Context)
+}
+
+func TestNonPersistentFlagsStayOnTheirLevel(t *testing.T) {
+	var root persistentRoot
+	_, err := ParseCommand(&root, "middle", "leaf", "--local", "-f")
+	require.NoError(t, err)
+
+	assert.False(t, root.Local)
+	assert.True(t, root.Middle.Force)
+}
+
+func TestPersistentWithoutFlagFails(t *testing.T) {
+	type Foo struct {
+		Value string `clapper:"persistent"`
+	}
+
+	_, err := Parse(&Foo{})
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrPersistentWithoutFlag, 0, "Value", "persistent", 1))
+}
+
+func TestPersistentHelp(t *testing.T) {
+	var root persistentRoot
+	help, err := HelpCommand(&root, DefaultHelpFormatter, "middle", "leaf")
+	require.NoError(t, err)
+
+	assert.Equal(t, "--name (default: none)\n"+
+		"Inherited flags:\n"+
+		"-v, --verbose                    - Verbose output\n"+
+		"--context     (default: default)\n"+
+		"-f, --force                     \n", help)
+
+	help, err = HelpDefault(&root)
+	require.NoError(t, err)
+	assert.NotContains(t, help, "Inherited flags:")
+}
+
+type shadowingSub struct {
+	Name string `clapper:"long,default=none"`
+}
+
+type shadowingRoot struct {
+	Name string        `clapper:"long,persistent,default=none"`
+	Sub  *shadowingSub `clapper:"subcommand"`
+}
+
+func TestPersistentFlagShadowed(t *testing.T) {
+	var root shadowingRoot
+	_, err := ParseCommand(&root, "--name", "root", "sub", "--name", "x")
+	require.NoError(t, err)
+
+	assert.Equal(t, "root", root.Name)
+	assert.Equal(t, "x", root.Sub.Name)
+
+	root = shadowingRoot{}
+	_, err = ParseCommand(&root, "sub", "--name", "x")
+	require.NoError(t, err)
+
+	assert.Equal(t, "none", root.Name)
+	assert.Equal(t, "x", root.Sub.Name)
+}
+
+func TestPersistentHelpShadowed(t *testing.T) {
+	var root shadowingRoot
+	help, err := HelpCommand(&root, DefaultHelpFormatter, "sub")
+	require.NoError(t, err)
+
+	assert.Equal(t, "--name (default: none)\n", help)
+}
+
+func TestStrictUnknownFlags(t *testing.T) {
+	type Foo struct {
+		DryRun bool   `clapper:"short,long"`
+		Name   string `clapper:"long,default=foo"`
+	}
+
+	var foo Foo
+	_, err := NewParser().WithStrict().Parse(&foo, "--dyr-run", "--name", "bar", "-x", "--dyr-run")
+	var unknownErr UnknownFlagError
+	require.ErrorAs(t, err, &unknownErr)
+	assert.Equal(t, []string{"--dyr-run", "-x"}, unknownErr.Flags)
+	assert.Equal(t, map[string][]string{"--dyr-run": {"--dry-run"}}, unknownErr.Suggestions)
+	assert.EqualError(t, err, "unknown flags --dyr-run (did you mean --dry-run?), -x")
+
+	_, err = NewParser().WithStrict().Parse(&foo, "--dry-run", "--name", "bar", "-D")
+	require.NoError(t, err)
+
+	_, err = Parse(&foo, "--dyr-run")
+	require.NoError(t, err)
+}
+
+func TestStrictSubcommands(t *testing.T) {
+	var root persistentRoot
+	_, err := NewParser().WithStrict().ParseCommand(&root, "middle", "-v", "leaf", "--force", "--name", "foo")
+	require.NoError(t, err)
+
+	_, err = NewParser().WithStrict().ParseCommand(&root, "middle", "--local", "leaf")
+	assert.EqualError(t, err, "unknown flag --local")
+}
+
+func TestStrictSuggestions(t *testing.T) {
+	type Foo struct {
+		Verbose bool `clapper:"short,long"`
+		Host    bool `clapper:"long"`
+		Post    bool `clapper:"long"`
+	}
+
+	var foo Foo
+	_, err := NewParser().WithStrict().Parse(&foo, "--verbsoe")
+	assert.EqualError(t, err, "unknown flag --verbsoe, did you mean --verbose?")
+
+	_, err = NewParser().WithStrict().Parse(&foo, "--hast")
+	assert.EqualError(t, err, "unknown flag --hast, did you mean --host or --post?")
+
+	_, err = NewParser().WithStrict().Parse(&foo, "--completely-different")
+	assert.EqualError(t, err, "unknown flag --completely-different")
+}
+
+func TestStrictUnknownCommand(t *testing.T) {
+	var git gitCmd
+	_, err := NewParser().WithStrict().ParseCommand(&git, "-C", "repo", "remote", "ad")
+	var unknownErr UnknownCommandError
+	require.ErrorAs(t, err, &unknownErr)
+	assert.Equal(t, "ad", unknownErr.Name)
+	assert.Equal(t, []string{"add"}, unknownErr.Suggestions)
+	assert.EqualError(t, err, "unknown command 'ad', did you mean 'add'?")
+
+	result, err := ParseCommand(&git, "remote", "ad")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ad"}, result.Trailing)
+
+	for _, args := range [][]string{{"remtoe"}, {"remtoe", "-C", "repo"}} {
+		_, err = NewParser().WithStrict().ParseCommand(&gitCmd{}, args...)
+		assert.EqualError(t, err, "unknown command 'remtoe', did you mean 'remote'?")
+	}
+}
+
+func TestStrictFlagWithoutName(t *testing.T) {
+	_, err := NewParser().WithStrict().ParseCommand(&gitCmd{}, "--=x")
+	assert.EqualError(t, err, "unknown flag --=x")
+
+	result, err := ParseCommand(&gitCmd{}, "--=x")
+	require.NoError(t, err)
+	assert.Empty(t, result.Path)
+	assert.Empty(t, result.Trailing)
+}
+
+func TestTerminatorEndsOptions(t *testing.T) {
+	type Foo struct {
+		Files []string `clapper:"short,long"`
+		Force bool     `clapper:"short=f"`
+	}
+
+	var foo Foo
+	trailing, err := Parse(&foo, "--files", "a", "b", "--", "-foo", "--bar=baz", "-f")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a", "b"}, foo.Files)
+	assert.False(t, foo.Force)
+	assert.Equal(t, []string{"-foo", "--bar=baz", "-f"}, trailing)
+}
+
+func TestTerminatorWithCommand(t *testing.T) {
+	type Foo struct {
+		Flag    bool     `clapper:"short"`
+		Command []string `clapper:"command"`
+	}
+
+	var foo Foo
+	trailing, err := Parse(&foo, "-F", "--", "-rf", "--no-preserve-root")
+	require.NoError(t, err)
+
+	assert.True(t, foo.Flag)
+	assert.Equal(t, []string{"-rf", "--no-preserve-root"}, foo.Command)
+	assert.Empty(t, trailing)
+}
+
+func TestTerminatorSingleCommand(t *testing.T) {
+	type Foo struct {
+		Command string `clapper:"command"`
+	}
+
+	var foo Foo
+	trailing, err := Parse(&foo, "--", "-foo", "bar")
+	require.NoError(t, err)
+
+	assert.Equal(t, "-foo", foo.Command)
+	assert.Equal(t, []string{"bar"}, trailing)
+}
+
+func TestTerminatorStopsSubcommands(t *testing.T) {
+	var git gitCmd
+	result, err := ParseCommand(&git, "remote", "--", "add", "-x")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"remote"}, result.Path)
+	assert.Equal(t, []string{"add", "-x"}, result.Trailing)
+	assert.Nil(t, git.Remote.Add)
+}
+
+func TestNegativeNumbers(t *testing.T) {
+	type Foo struct {
+		Offset int       `clapper:"long"`
+		Temp   float64   `clapper:"short,long"`
+		Deltas []float32 `clapper:"short,long"`
+		Opt    *int      `clapper:"long"`
+	}
+
+	var foo Foo
+	trailing, err := Parse(&foo, "--offset", "-5", "-T", "-3.5", "--deltas", "-1", "2", "-.5", "--opt=-35", "-7")
+	require.NoError(t, err)
+
+	assert.Equal(t, -5, foo.Offset)
+	assert.Equal(t, -3.5, foo.Temp)
+	assert.Equal(t, []float32{-1, 2, -0.5}, foo.Deltas)
+	require.NotNil(t, foo.Opt)
+	assert.Equal(t, -35, *foo.Opt)
+	assert.Equal(t, []string{"-7"}, trailing)
+}
+
+func TestNegativeNumbersWithDigitShorts(t *testing.T) {
+	type Foo struct {
+		Offset int  `clapper:"long"`
+		One    bool `clapper:"short=1"`
+		Two    bool `clapper:"short=2"`
+	}
+
+	var foo Foo
+	_, err := Parse(&foo, "--offset", "-12")
+	require.NoError(t, err)
+	assert.Equal(t, -12, foo.Offset)
+	assert.False(t, foo.One)
+	assert.False(t, foo.Two)
+
+	foo = Foo{}
+	_, err = Parse(&foo, "--offset", "3", "-12")
+	require.NoError(t, err)
+	assert.Equal(t, 3, foo.Offset)
+	assert.True(t, foo.One)
+	assert.True(t, foo.Two)
+}
+
+func TestNegativeNumberAsCommand(t *testing.T) {
+	type Foo struct {
+		Flag    bool `clapper:"short"`
+		Command int  `clapper:"command"`
+	}
+
+	var foo Foo
+	_, err := Parse(&foo, "-F", "-42")
+	require.NoError(t, err)
+	assert.Equal(t, -42, foo.Command)
+}
+
+func TestAttachedShortValues(t *testing.T) {
+	type Foo struct {
+		Port    int    `clapper:"short=p"`
+		Output  string `clapper:"short=o"`
+		Verbose bool   `clapper:"short=v"`
+		All     bool   `clapper:"short=a"`
+	}
+
+	var foo Foo
+	trailing, err := Parse(&foo, "-p8080", "-vaofile.txt", "rest")
+	require.NoError(t, err)
+
+	assert.Equal(t, 8080, foo.Port)
+	assert.Equal(t, "file.txt", foo.Output)
+	assert.True(t, foo.Verbose)
+	assert.True(t, foo.All)
+	assert.Equal(t, []string{"rest"}, trailing)
+}
+
+func TestAttachedNegativeShortValue(t *testing.T) {
+	type Foo struct {
+		Number int  `clapper:"short=n"`
+		One    bool `clapper:"short=1"`
+	}
+
+	var foo Foo
+	_, err := Parse(&foo, "-n-12")
+	require.NoError(t, err)
+	assert.Equal(t, -12, foo.Number)
+	assert.False(t, foo.One)
+}
+
+func TestCombinedShortsWithSeparateValue(t *testing.T) {
+	type Foo struct {
+		S bool `clapper:"short=s"`
+		A bool `clapper:"short=a"`
+		D int  `clapper:"short=d"`
+	}
+
+	var foo Foo
+	_, err := Parse(&foo, "-sad", "123")
+	require.NoError(t, err)
+	assert.True(t, foo.S)
+	assert.True(t, foo.A)
+	assert.Equal(t, 123, foo.D)
+}
+
+func TestCountFlags(t *testing.T) {
+	type Foo struct {
+		Verbosity int  `clapper:"short=v,long=verbose,count"`
+		Quiet     uint `clapper:"short=q,count"`
+		Force     bool `clapper:"short=f"`
+	}
+
+	tests := []struct {
+		name      string
+		args      []string
+		verbosity int
+		quiet     uint
+		trailing  []string
+	}{
+		{name: "absent", args: []string{"-f"}, verbosity: 0},
+		{name: "separate", args: []string{"-v", "-v", "-v"}, verbosity: 3},
+		{name: "combined", args: []string{"-vvv"}, verbosity: 3},
+		{name: "long", args: []string{"--verbose", "--verbose"}, verbosity: 2},
+		{name: "mixed", args: []string{"-vf", "--verbose", "-qvq"}, verbosity: 3, quiet: 2},
+		{name: "no value", args: []string{"-v", "1"}, verbosity: 1, trailing: []string{"1"}},
+		{name: "no negative value", args: []string{"-v", "-1"}, verbosity: 1, trailing: []string{"-1"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var foo Foo
+			trailing, err := Parse(&foo, test.args...)
+			require.NoError(t, err)
+			assert.Equal(t, test.verbosity, foo.Verbosity)
+			assert.Equal(t, test.quiet, foo.Quiet)
+			if test.trailing == nil {
+				assert.Empty(t, trailing)
+			} else {
+				assert.Equal(t, test.trailing, trailing)
+			}
+		})
+	}
+}
+
+func TestCountFallback(t *testing.T) {
+	type Foo struct {
+		Verbosity int `clapper:"short=v,env=VERBOSITY,default=1,count"`
+	}
+
+	var foo Foo
+	_, err := Parse(&foo)
+	require.NoError(t, err)
+	assert.Equal(t, 1, foo.Verbosity)
+
+	t.Setenv("VERBOSITY", "2")
+	_, err = Parse(&foo)
+	require.NoError(t, err)
+	assert.Equal(t, 2, foo.Verbosity)
+
+	_, err = Parse(&foo, "-vvvv")
+	require.NoError(t, err)
+	assert.Equal(t, 4, foo.Verbosity)
+}
+
+func TestCountInvalid(t *testing.T) {
+	type NoInteger struct {
+		Verbose bool `clapper:"short,count"`
+	}
+	type NoFlag struct {
+		Verbosity int `clapper:"count"`
+	}
+	type WithValue struct {
+		Verbosity int `clapper:"short,count=2"`
+	}
+
+	_, err := Parse(&NoInteger{})
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrCountNoInteger, 0, "Verbose", "short,count", 7))
+	_, err = Parse(&NoFlag{})
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrCountNoInteger, 0, "Verbosity", "count", 1))
+	_, err = Parse(&WithValue{})
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrCountCanNotHaveValue, 0, "Verbosity", "short,count=2", 7))
+}
+
+func TestCountAssignedValue(t *testing.T) {
+	type Foo struct {
+		Verbosity int `clapper:"short=v,long=verbose,count"`
+	}
+
+	for _, args := range [][]string{{"--verbose=3"}, {"-v=3"}, {"-v", "--verbose=3"}} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			_, err := Parse(&Foo{}, args...)
+			var argErr ArgumentError
+			require.ErrorAs(t, err, &argErr)
+			assert.Equal(t, len(args), argErr.Position)
+			var formatErr UnexpectedInputFormatError
+			assert.ErrorAs(t, err, &formatErr)
+		})
+	}
+}
+
+func TestNegatableBool(t *testing.T) {
+	type Foo struct {
+		Color bool `clapper:"short=c,long,negatable,default=true"`
+		Force bool `clapper:"short=f,long"`
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		color    bool
+		force    bool
+		trailing []string
+	}{
+		{name: "default", args: []string{"-f"}, color: true, force: true},
+		{name: "negated", args: []string{"--no-color"}, color: false},
+		{name: "explicit false", args: []string{"--color=false", "--force=yes"}, color: false, force: true},
+		{name: "explicit true", args: []string{"--color=1", "--force=no"}, color: true, force: false},
+		{name: "negated explicit", args: []string{"--no-color=false"}, color: true},
+		{name: "short explicit", args: []string{"-fc=no"}, color: false, force: true},
+		{name: "first wins", args: []string{"--no-color", "--color"}, color: false},
+		{name: "long before short", args: []string{"-c=false", "--color"}, color: true},
+		{name: "no value swallowed", args: []string{"--color", "false"}, color: true, trailing: []string{"false"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var foo Foo
+			trailing, err := NewParser().WithStrict().Parse(&foo, test.args...)
+			require.NoError(t, err)
+			assert.Equal(t, test.color, foo.Color)
+			assert.Equal(t, test.force, foo.Force)
+			if test.trailing == nil {
+				assert.Empty(t, trailing)
+			} else {
+				assert.Equal(t, test.trailing, trailing)
+			}
+		})
+	}
+}
+
+func TestBoolMalformedValue(t *testing.T) {
+	type Foo struct {
+		Color bool `clapper:"long"`
+	}
+
+	for _, value := range []string{"maybe", "t", "F", "TRUE", "True", "YES", ""} {
+		var foo Foo
+		_, err := Parse(&foo, "--color="+value)
+		assert.ErrorIs(t, err, NewUnexpectedInputFormatError(value, reflect.TypeOf(true)))
+	}
+}
+
+func TestNegatableInvalid(t *testing.T) {
+	type NoBool struct {
+		Name *string `clapper:"long,negatable"`
+	}
+	type NoLong struct {
+		Color bool `clapper:"short,negatable"`
+	}
+	type WithValue struct {
+		Color bool `clapper:"long,negatable=off"`
+	}
+
+	_, err := Parse(&NoBool{})
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrNegatableNoBool, 0, "Name", "long,negatable", 6))
+	_, err = Parse(&NoLong{})
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrNegatableNoBool, 0, "Color", "short,negatable", 7))
+	_, err = Parse(&WithValue{})
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrNegatableCanNotHaveValue, 0, "Color", "long,negatable=off", 6))
+}
+
+func TestNegatableHelp(t *testing.T) {
+	type Foo struct {
+		Color bool `clapper:"short,long,negatable,help=Colorize output"`
+	}
+
+	help, err := HelpDefault(&Foo{})
+	require.NoError(t, err)
+	assert.Equal(t, "-c, --color, --no-color  - Colorize output\n", help)
+}
+
+func TestTriStateBool(t *testing.T) {
+	type Foo struct {
+		Cache *bool `clapper:"long,negatable"`
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected *bool
+	}{
+		{name: "unset", args: []string{"--nope"}, expected: nil},
+		{name: "forced on", args: []string{"--cache"}, expected: ptr(true)},
+		{name: "forced off", args: []string{"--no-cache"}, expected: ptr(false)},
+		{name: "explicitly off", args: []string{"--cache=false"}, expected: ptr(false)},
+		{name: "explicitly on", args: []string{"--cache=yes"}, expected: ptr(true)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var foo Foo
+			_, err := Parse(&foo, test.args...)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, foo.Cache)
+		})
+	}
+}
+
+func TestBoolDefaults(t *testing.T) {
+	type Foo struct {
+		Debug bool  `clapper:"long,default=false"`
+		Cache *bool `clapper:"long,negatable,default=false"`
+		Color *bool `clapper:"long,default=true"`
+	}
+
+	var foo Foo
+	_, err := Parse(&foo, "--nope")
+	require.NoError(t, err)
+	assert.False(t, foo.Debug)
+	assert.Equal(t, ptr(false), foo.Cache)
+	assert.Equal(t, ptr(true), foo.Color)
+
+	foo = Foo{}
+	_, err = Parse(&foo, "--debug", "--cache", "--color=no")
+	require.NoError(t, err)
+	assert.True(t, foo.Debug)
+	assert.Equal(t, ptr(true), foo.Cache)
+	assert.Equal(t, ptr(false), foo.Color)
+}
+
+func TestTriStateBoolHelp(t *testing.T) {
+	type Foo struct {
+		Cache *bool `clapper:"long,negatable,help=Use the build cache"`
+	}
+
+	help, err := HelpDefault(&Foo{})
+	require.NoError(t, err)
+	assert.Equal(t, "--cache, --no-cache  - Use the build cache\n", help)
+}
+
+func TestRepeatPolicy(t *testing.T) {
+	type Foo struct {
+		Color string `clapper:"short=c,long,default=auto"`
+		Cache bool   `clapper:"long,negatable"`
+	}
+
+	tests := []struct {
+		name     string
+		policy   RepeatPolicy
+		args     []string
+		color    string
+		cache    bool
+		trailing []string
+		err      string
+	}{
+		{
+			name:     "first wins",
+			policy:   RepeatFirstWins,
+			args:     []string{"--color", "always", "--color", "never", "--no-cache", "--cache", "rest"},
+			color:    "always",
+			cache:    false,
+			trailing: []string{"rest"},
+		},
+		{
+			name:   "first wins prefers long",
+			policy: RepeatFirstWins,
+			args:   []string{"-c", "always", "--color", "never"},
+			color:  "never",
+		},
+		{
+			name:     "last wins",
+			policy:   RepeatLastWins,
+			args:     []string{"--color=always", "--color", "never", "--no-cache", "--cache", "rest"},
+			color:    "never",
+			cache:    true,
+			trailing: []string{"rest"},
+		},
+		{
+			name:   "last wins across spellings",
+			policy: RepeatLastWins,
+			args:   []string{"--color", "never", "-c", "always"},
+			color:  "always",
+		},
+		{
+			name:   "error",
+			policy: RepeatError,
+			args:   []string{"--color", "never", "-c", "always"},
+			err:    "argument 3: flag -c given more than once",
+		},
+		{
+			name:   "error on bool",
+			policy: RepeatError,
+			args:   []string{"--cache", "--no-cache"},
+			err:    "argument 2: flag --no-cache given more than once",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var foo Foo
+			trailing, err := NewParser().WithRepeatPolicy(test.policy).Parse(&foo, test.args...)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.color, foo.Color)
+			assert.Equal(t, test.cache, foo.Cache)
+			if test.trailing == nil {
+				assert.Empty(t, trailing)
+			} else {
+				assert.Equal(t, test.trailing, trailing)
+			}
+		})
+	}
+}
+
+func TestRepeatedFlagError(t *testing.T) {
+	type Foo struct {
+		Name string   `clapper:"long"`
+		Tags []string `clapper:"long"`
+	}
+
+	var foo Foo
+	_, err := NewParser().WithRepeatPolicy(RepeatError).Parse(&foo, "--name", "a", "--name", "b", "--tags", "x")
+	assert.ErrorIs(t, err, NewRepeatedFlagError("--name"))
+
+	// Slices take the values of all occurrences.
+	_, err = NewParser().WithRepeatPolicy(RepeatError).Parse(&foo, "--name", "a", "--tags", "x", "--tags", "y")
+	require.NoError(t, err)
+	assert.Equal(t, "a", foo.Name)
+	assert.Equal(t, []string{"x", "y"}, foo.Tags)
+}
+
+func TestMalformedValueWithDefault(t *testing.T) {
+	type Foo struct {
+		Port int `clapper:"short=p,long,default=80"`
+	}
+
+	var foo Foo
+	_, err := Parse(&foo, "--port", "http")
+	assert.ErrorIs(t, err, NewUnexpectedInputFormatError("http", reflect.TypeOf(0)))
+}
+
+func TestNargs(t *testing.T) {
+	type Foo struct {
+		Tags    []string  `clapper:"short=t,long,nargs=1,default=none"`
+		Pair    []string  `clapper:"long,nargs=2,default=none"`
+		Range   []int     `clapper:"long,nargs=1..3,default=0"`
+		Command []string  `clapper:"command"`
+		Offsets []float64 `clapper:"long,nargs=2,default=0"`
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		tags    []string
+		pair    []string
+		rng     []int
+		offsets []float64
+		command []string
+		err     string
+	}{
+		{
+			name:    "single value leaves command",
+			args:    []string{"--tags", "a", "b", "run"},
+			tags:    []string{"a"},
+			command: []string{"b", "run"},
+		},
+		{
+			name:    "repeated occurrences add values",
+			args:    []string{"--tags", "a", "-t", "b", "--tags", "c", "run"},
+			tags:    []string{"a", "b", "c"},
+			command: []string{"run"},
+		},
+		{
+			name:    "exact count",
+			args:    []string{"--pair", "a", "b", "run"},
+			pair:    []string{"a", "b"},
+			command: []string{"run"},
+		},
+		{
+			name:    "range takes at most maximum",
+			args:    []string{"--range", "1", "2", "3", "4"},
+			rng:     []int{1, 2, 3},
+			command: []string{"4"},
+		},
+		{
+			name:    "range takes what is given",
+			args:    []string{"--range", "1", "--range", "2", "3", "--tags", "a", "run"},
+			rng:     []int{1, 2, 3},
+			tags:    []string{"a"},
+			command: []string{"run"},
+		},
+		{
+			name:    "negative numbers up to the maximum",
+			args:    []string{"--offsets", "-1", "-2.5", "run"},
+			offsets: []float64{-1, -2.5},
+			command: []string{"run"},
+		},
+		{
+			name: "too few values",
+			args: []string{"--pair", "a", "--tags", "b", "run"},
+			err:  "argument 1: flag --pair requires at least 2 values but got 1",
+		},
+		{
+			name: "missing value",
+			args: []string{"--tags", "--pair", "a", "b", "run"},
+			err:  "argument 1: flag --tags requires at least 1 value but got 0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var foo Foo
+			_, err := Parse(&foo, test.args...)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			if test.tags != nil {
+				assert.Equal(t, test.tags, foo.Tags)
+			}
+			if test.pair != nil {
+				assert.Equal(t, test.pair, foo.Pair)
+			}
+			if test.rng != nil {
+				assert.Equal(t, test.rng, foo.Range)
+			}
+			if test.offsets != nil {
+				assert.Equal(t, test.offsets, foo.Offsets)
+			}
+			assert.Equal(t, test.command, foo.Command)
+		})
+	}
+}
+
+func TestNargsBeforeSubcommand(t *testing.T) {
+	type Run struct{}
+	type Foo struct {
+		Pair []string `clapper:"long,nargs=2"`
+		Run  *Run     `clapper:"subcommand"`
+	}
+
+	var foo Foo
+	result, err := ParseCommand(&foo, "--pair", "run", "x", "run")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"run", "x"}, foo.Pair)
+	assert.Equal(t, []string{"run"}, result.Path)
+}
+
+func TestNargsInvalid(t *testing.T) {
+	type NoSlice struct {
+		Name string `clapper:"long,nargs=1"`
+	}
+	type Malformed struct {
+		Tags []string `clapper:"long,nargs=3..1"`
+	}
+	type Zero struct {
+		Tags []string `clapper:"long,nargs=0"`
+	}
+
+	_, err := Parse(&NoSlice{})
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrNargsNoSlice, 0, "Name", "long,nargs=1", 6))
+	_, err = Parse(&Malformed{})
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrInvalidNargs, 0, "Tags", "long,nargs=3..1", 6))
+	_, err = Parse(&Zero{})
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrInvalidNargs, 0, "Tags", "long,nargs=0", 6))
+}
+
+func TestAttachedValueBeforeSubcommand(t *testing.T) {
+	type Run struct{}
+	type Foo struct {
+		Port int  `clapper:"short=p"`
+		Run  *Run `clapper:"subcommand"`
+	}
+
+	var foo Foo
+	result, err := ParseCommand(&foo, "-p8080", "run")
+	require.NoError(t, err)
+	assert.Equal(t, 8080, foo.Port)
+	assert.Equal(t, []string{"run"}, result.Path)
+}
+
+func TestSeparator(t *testing.T) {
+	type Foo struct {
+		Tags   []string `clapper:"short=t,long,sep,default=a\\,b"`
+		Ports  []int    `clapper:"long,sep=;,env=PORTS,default=80"`
+		Hosts  []string `clapper:"long,sep,nargs=1,default=localhost"`
+		Labels []string `clapper:"long,default=x\\,y"`
+	}
+
+	var foo Foo
+	_, err := Parse(&foo, "--nope")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, foo.Tags)
+	assert.Equal(t, []int{80}, foo.Ports)
+	assert.Equal(t, []string{"localhost"}, foo.Hosts)
+	assert.Equal(t, []string{"x,y"}, foo.Labels)
+
+	t.Setenv("PORTS", "80;443")
+	foo = Foo{}
+	trailing, err := Parse(&foo, "--tags", "a,b", "--tags=c", "--hosts", "h1,h2", "rest", "--hosts", "h3")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, foo.Tags)
+	assert.Equal(t, []int{80, 443}, foo.Ports)
+	assert.Equal(t, []string{"h1", "h2", "h3"}, foo.Hosts)
+	assert.Empty(t, trailing)
+
+	_, err = Parse(&foo, "--ports", "80;http")
+	assert.ErrorIs(t, err, NewUnexpectedInputFormatError("http", reflect.TypeOf(0)))
+}
+
+func TestSeparatorInvalid(t *testing.T) {
+	type Foo struct {
+		Name string `clapper:"long,sep"`
+	}
+
+	_, err := Parse(&Foo{})
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrSepNoSlice, 0, "Name", "long,sep", 6))
+}
+
+func TestQuotedTagValues(t *testing.T) {
+	type Foo struct {
+		Server string   `clapper:"long,default='localhost:8080',help='Host, port and path'"`
+		Tags   []string `clapper:"long,sep,default=\"a,b\""`
+		Force  bool     `clapper:"long,help=Don't ask"`
+	}
+
+	var foo Foo
+	_, err := Parse(&foo, "--nope")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:8080", foo.Server)
+	assert.Equal(t, []string{"a", "b"}, foo.Tags)
+
+	help, err := HelpDefault(&foo)
+	require.NoError(t, err)
+	assert.Contains(t, help, "(default: localhost:8080) - Host, port and path\n")
+	assert.Contains(t, help, "- Don't ask\n")
+}
+
+func TestTagSyntaxErrors(t *testing.T) {
+	type Unterminated struct {
+		Help bool `clapper:"long,help='Display help"`
+	}
+	type AfterQuote struct {
+		Help bool `clapper:"long,help='Display' help"`
+	}
+
+	_, err := Parse(&Unterminated{})
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrUnterminatedQuote, 0, "Help", "long,help='Display help", 11))
+	assert.EqualError(t, err,
+		"parse error 'unterminated quote' at index 0, column 11: field 'Help' (tag-line: long,help='Display help)")
+
+	_, err = Parse(&AfterQuote{})
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrUnexpectedAfterQuote, 0, "Help", "long,help='Display' help", 20))
+}
+
+func TestMapFields(t *testing.T) {
+	type Foo struct {
+		Labels  map[string]string `clapper:"short=l,long=label"`
+		Limits  map[string]int    `clapper:"long=limit,sep,default='cpu=1,memory=512'"`
+		Command []string          `clapper:"command"`
+	}
+
+	var foo Foo
+	_, err := Parse(&foo, "--label", "env=prod", "--label", "team=core", "--", "run", "a=b")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "prod", "team": "core"}, foo.Labels)
+	assert.Equal(t, map[string]int{"cpu": 1, "memory": 512}, foo.Limits)
+	assert.Equal(t, []string{"run", "a=b"}, foo.Command)
+
+	foo = Foo{}
+	_, err = Parse(&foo, "-l=env=prod", "url=a=b", "--limit", "cpu=2", "--", "set", "a=b")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "prod", "url": "a=b"}, foo.Labels)
+	assert.Equal(t, map[string]int{"cpu": 2}, foo.Limits)
+	assert.Equal(t, []string{"set", "a=b"}, foo.Command)
+
+	_, err = Parse(&foo, "--label", "env", "--", "run")
+	assert.ErrorIs(t, err, NewUnexpectedInputFormatError("env", reflect.TypeOf(foo.Labels)))
+
+	_, err = Parse(&foo, "--label", "a=b", "--limit", "cpu=many", "--", "run")
+	assert.ErrorIs(t, err, NewUnexpectedInputFormatError("many", reflect.TypeOf(0)))
+}
+
+func TestMapDuplicateKeys(t *testing.T) {
+	type Foo struct {
+		Labels map[string]string `clapper:"long=label,nargs=1"`
+	}
+
+	tests := []struct {
+		policy   RepeatPolicy
+		expected map[string]string
+		err      error
+	}{
+		{policy: RepeatFirstWins, expected: map[string]string{"env": "prod", "team": "core"}},
+		{policy: RepeatLastWins, expected: map[string]string{"env": "dev", "team": "core"}},
+		{policy: RepeatError, err: NewDuplicateKeyError("env")},
+	}
+
+	for _, test := range tests {
+		var foo Foo
+		_, err := NewParser().WithRepeatPolicy(test.policy).
+			Parse(&foo, "--label", "env=prod", "--label", "team=core", "--label", "env=dev")
+		if test.err != nil {
+			assert.ErrorIs(t, err, test.err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, foo.Labels)
+	}
+}
+
+type setCmd struct {
+	Force  bool   `clapper:"short=f"`
+	Define string `clapper:"short=D,default=none"`
+}
+
+type serveCmd struct {
+	Port int `clapper:"short=p,long"`
+}
+
+type assignmentCmd struct {
+	Verbose bool      `clapper:"short=v,long"`
+	Set     *setCmd   `clapper:"subcommand"`
+	Serve   *serveCmd `clapper:"subcommand"`
+}
+
+func TestAssignmentsOnlySplitFlags(t *testing.T) {
+	var cmd assignmentCmd
+	trailing, err := NewParser().Parse(&cmd, "set", "-f", "-Dkey=value", "a=b", "--", "c=d")
+	require.NoError(t, err)
+	require.NotNil(t, cmd.Set)
+	assert.True(t, cmd.Set.Force)
+	assert.Equal(t, "key=value", cmd.Set.Define)
+	assert.Equal(t, []string{"a=b", "c=d"}, trailing)
+}
+
+func TestArgumentErrorPosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		position int
+	}{
+		{name: "assigned long", args: []string{"-v", "serve", "--port=http"}, position: 3},
+		{name: "attached short", args: []string{"-v", "serve", "-v", "-pnope"}, position: 4},
+		{name: "separate value", args: []string{"serve", "--port", "http"}, position: 3},
+		{name: "assigned bool", args: []string{"--verbose=maybe", "serve"}, position: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var cmd assignmentCmd
+			_, err := NewParser().Parse(&cmd, test.args...)
+			var argErr ArgumentError
+			require.ErrorAs(t, err, &argErr)
+			assert.Equal(t, test.position, argErr.Position)
+			assert.ErrorAs(t, err, &UnexpectedInputFormatError{})
+		})
+	}
+}
+
+func TestArgumentErrorPositionMultiValue(t *testing.T) {
+	type Foo struct {
+		Ints    []int          `clapper:"long,default=0"`
+		Map     map[string]int `clapper:"long,default=a=0"`
+		CSV     []int          `clapper:"long,sep,default=0"`
+		Pair    []int          `clapper:"long,nargs=2,default=0"`
+		Command []int          `clapper:"command"`
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		position int
+	}{
+		{name: "slice", args: []string{"--ints", "1", "x"}, position: 3},
+		{name: "assigned slice", args: []string{"--ints=x"}, position: 1},
+		{name: "map value", args: []string{"--map", "a=1", "b=x"}, position: 3},
+		{name: "map without key", args: []string{"--map", "a=1", "b"}, position: 3},
+		{name: "separated", args: []string{"--csv", "1", "2,x"}, position: 3},
+		{name: "nargs", args: []string{"--pair", "1", "2", "--pair", "3", "x"}, position: 6},
+		{name: "command", args: []string{"--", "1", "x"}, position: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(&Foo{}, test.args...)
+			var argErr ArgumentError
+			require.ErrorAs(t, err, &argErr)
+			assert.Equal(t, test.position, argErr.Position)
+			assert.ErrorAs(t, err, &UnexpectedInputFormatError{})
+		})
+	}
+}
+
+func TestIntegerKinds(t *testing.T) {
+	type Foo struct {
+		Int8    int8              `clapper:"long"`
+		Int16   int16             `clapper:"long"`
+		Int32   *int32            `clapper:"long"`
+		Int64   int64             `clapper:"long"`
+		Uint    uint              `clapper:"long"`
+		Uint8   uint8             `clapper:"long"`
+		Uint16  uint16            `clapper:"long"`
+		Uint32  uint32            `clapper:"long"`
+		Uint64  uint64            `clapper:"long"`
+		Small   []int8            `clapper:"long,default=1"`
+		Weights map[string]uint16 `clapper:"long,default=a=1"`
+	}
+
+	var foo Foo
+	_, err := Parse(&foo,
+		"--int8", "-128", "--int16", "32767", "--int32", "-5", "--int64", "9223372036854775807",
+		"--uint", "7", "--uint8", "255", "--uint16", "65535", "--uint32", "4294967295", "--uint64", "18446744073709551615",
+		"--small", "-1", "2", "--weights", "a=1", "b=65535")
+	require.NoError(t, err)
+	assert.Equal(t, Foo{
+		Int8:    -128,
+		Int16:   32767,
+		Int32:   ptr[int32](-5),
+		Int64:   math.MaxInt64,
+		Uint:    7,
+		Uint8:   255,
+		Uint16:  65535,
+		Uint32:  math.MaxUint32,
+		Uint64:  math.MaxUint64,
+		Small:   []int8{-1, 2},
+		Weights: map[string]uint16{"a": 1, "b": 65535},
+	}, foo)
+}
+
+func TestIntegerRange(t *testing.T) {
+	type Foo struct {
+		Int8   int8             `clapper:"long,default=0"`
+		Uint8  uint8            `clapper:"long,default=0"`
+		Uint64 uint64           `clapper:"long,default=0"`
+		Small  []int16          `clapper:"long,default=0"`
+		Counts map[uint8]string `clapper:"long,default=0=a"`
+		Level  int8             `clapper:"short=v,count"`
+		Width  *uint16          `clapper:"long"`
+	}
+
+	int8Type := reflect.TypeOf(int8(0))
+	uint8Type := reflect.TypeOf(uint8(0))
+	tests := []struct {
+		name string
+		args []string
+		err  error
+		msg  string
+	}{
+		{
+			name: "signed overflow",
+			args: []string{"--int8", "128"},
+			err:  NewUnexpectedInputFormatErrorExpecting("128", int8Type, "int8 between -128 and 127"),
+			msg:  "argument 2: unexpected input format. given '128', expected int8 between -128 and 127",
+		},
+		{
+			name: "signed underflow",
+			args: []string{"--int8=-129"},
+			err:  NewUnexpectedInputFormatErrorExpecting("-129", int8Type, "int8 between -128 and 127"),
+		},
+		{
+			name: "negative unsigned",
+			args: []string{"--uint8", "-1"},
+			err:  NewUnexpectedInputFormatErrorExpecting("-1", uint8Type, "uint8 between 0 and 255"),
+		},
+		{
+			name: "unsigned overflow",
+			args: []string{"--uint64", "18446744073709551616"},
+			err: NewUnexpectedInputFormatErrorExpecting("18446744073709551616", reflect.TypeOf(uint64(0)),
+				"uint64 between 0 and 18446744073709551615"),
+		},
+		{
+			name: "no number",
+			args: []string{"--uint8", "many"},
+			err:  NewUnexpectedInputFormatError("many", uint8Type),
+		},
+		{
+			name: "slice element",
+			args: []string{"--small", "1", "40000"},
+			err: NewUnexpectedInputFormatErrorExpecting("40000", reflect.TypeOf(int16(0)),
+				"int16 between -32768 and 32767"),
+		},
+		{
+			name: "map key",
+			args: []string{"--counts", "256=a"},
+			err:  NewUnexpectedInputFormatErrorExpecting("256", uint8Type, "uint8 between 0 and 255"),
+		},
+		{
+			name: "pointer",
+			args: []string{"--width", "-3"},
+			err: NewUnexpectedInputFormatErrorExpecting("-3", reflect.TypeOf(uint16(0)),
+				"uint16 between 0 and 65535"),
+		},
+		{
+			name: "count",
+			args: strings.Split(strings.Repeat("-v ", 128), " ")[:128],
+			err:  NewUnexpectedInputFormatErrorExpecting("128", int8Type, "int8 between -128 and 127"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var foo Foo
+			_, err := Parse(&foo, test.args...)
+			assert.ErrorIs(t, err, test.err)
+			if test.msg != "" {
+				assert.EqualError(t, err, test.msg)
+			}
+		})
+	}
+}
+
+func TestNumericLiterals(t *testing.T) {
+	type Foo struct {
+		Mode  *uint32 `clapper:"long"`
+		Mask  uint8   `clapper:"long,default=0"`
+		Count int     `clapper:"long,default=0"`
+		Flags []int16 `clapper:"long,default=0"`
+	}
+
+	tests := []struct {
+		name     string
+		literals bool
+		args     []string
+		expected Foo
+		err      error
+	}{
+		{
+			name:     "go literals",
+			literals: true,
+			args:     []string{"--mode", "0o644", "--mask", "0xFF", "--count=-1_000_000", "--flags", "0b1010", "0o17", "017"},
+			expected: Foo{Mode: ptr[uint32](0o644), Mask: 0xFF, Count: -1_000_000, Flags: []int16{10, 15, 15}},
+		},
+		{
+			name:     "range is checked",
+			literals: true,
+			args:     []string{"--mask", "0x100"},
+			err:      NewUnexpectedInputFormatErrorExpecting("0x100", reflect.TypeOf(uint8(0)), "uint8 between 0 and 255"),
+		},
+		{
+			name:     "decimal only by default",
+			literals: false,
+			args:     []string{"--mask", "0xFF"},
+			err:      NewUnexpectedInputFormatError("0xFF", reflect.TypeOf(uint8(0))),
+		},
+		{
+			name:     "no leading zero octal by default",
+			literals: false,
+			args:     []string{"--count", "017"},
+			expected: Foo{Count: 17, Flags: []int16{0}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parser := NewParser()
+			if test.literals {
+				parser.WithNumericLiterals()
+			}
+			var foo Foo
+			_, err := parser.Parse(&foo, test.args...)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, foo)
+		})
+	}
+}
+
+func TestUnits(t *testing.T) {
+	type Foo struct {
+		MaxMem uint64            `clapper:"long,units=B,default=1GiB"`
+		Rate   int               `clapper:"long,units,default=0"`
+		Sizes  []int32           `clapper:"long,units,default=1k"`
+		Limits map[string]uint16 `clapper:"long,units,default=a=1"`
+		Plain  int               `clapper:"long,default=0"`
+	}
+
+	int32Type := reflect.TypeOf(int32(0))
+	tests := []struct {
+		name     string
+		literals bool
+		args     []string
+		expected Foo
+		err      error
+	}{
+		{
+			name: "defaults",
+			args: []string{"--plain", "1"},
+			expected: Foo{
+				MaxMem: 1 << 30, Sizes: []int32{1000}, Limits: map[string]uint16{"a": 1}, Plain: 1,
+			},
+		},
+		{
+			name: "si and iec prefixes",
+			args: []string{"--max-mem", "512MiB", "--rate", "-10k", "--sizes", "2Ki", "3M", "4", "--limits", "x=63Ki"},
+			expected: Foo{
+				MaxMem: 512 << 20, Rate: -10_000, Sizes: []int32{2048, 3_000_000, 4}, Limits: map[string]uint16{"x": 63 << 10},
+			},
+		},
+		{
+			name: "unit symbol is optional",
+			args: []string{"--max-mem", "2k", "--rate", "3K"},
+			expected: Foo{
+				MaxMem: 2000, Rate: 3000, Sizes: []int32{1000}, Limits: map[string]uint16{"a": 1},
+			},
+		},
+		{
+			name:     "with literals",
+			literals: true,
+			args:     []string{"--max-mem", "0x1EKiB", "--rate", "0x1E"},
+			expected: Foo{
+				MaxMem: 30 << 10, Rate: 30, Sizes: []int32{1000}, Limits: map[string]uint16{"a": 1},
+			},
+		},
+		{
+			name: "overflow after scaling",
+			args: []string{"--sizes", "3Gi"},
+			err:  NewUnexpectedInputFormatErrorExpecting("3Gi", int32Type, "int32 between -2147483648 and 2147483647"),
+		},
+		{
+			name: "overflow of map value",
+			args: []string{"--limits", "x=64Ki"},
+			err:  NewUnexpectedInputFormatErrorExpecting("64Ki", reflect.TypeOf(uint16(0)), "uint16 between 0 and 65535"),
+		},
+		{
+			name: "unknown prefix",
+			args: []string{"--sizes", "3Xi"},
+			err: NewUnexpectedInputFormatErrorExpecting("3Xi", int32Type,
+				"int32 with an optional unit prefix like 10k or 512Mi"),
+		},
+		{
+			name: "no fractions",
+			args: []string{"--max-mem", "1.5GiB"},
+			err: NewUnexpectedInputFormatErrorExpecting("1.5GiB", reflect.TypeOf(uint64(0)),
+				"uint64 with an optional unit prefix like 10kB or 512MiB"),
+		},
+		{
+			name: "only with units tag",
+			args: []string{"--plain", "1k"},
+			err:  NewUnexpectedInputFormatError("1k", reflect.TypeOf(0)),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parser := NewParser()
+			if test.literals {
+				parser.WithNumericLiterals()
+			}
+			var foo Foo
+			_, err := parser.Parse(&foo, test.args...)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, foo)
+		})
+	}
+}
+
+func TestUnitsInvalid(t *testing.T) {
+	type Foo struct {
+		Name string `clapper:"long,units"`
+	}
+	var foo Foo
+	_, err := Parse(&foo, "--nope")
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrUnitsNoInteger, 0, "Name", "long,units", 6))
+}
+
+func TestDurationAndTime(t *testing.T) {
+	type Foo struct {
+		Timeout  time.Duration            `clapper:"long,default=30s"`
+		Retries  []time.Duration          `clapper:"long,default=1s"`
+		Budget   *time.Duration           `clapper:"long"`
+		Since    time.Time                `clapper:"long,default=2024-01-02T03:04:05Z"`
+		Day      *time.Time               `clapper:"long,layout=date"`
+		Epoch    time.Time                `clapper:"long,layout=unix,default=0"`
+		Custom   time.Time                `clapper:"long,layout='02 Jan 06 15:04 MST',default='01 Feb 24 10:00 UTC'"`
+		Holidays []time.Time              `clapper:"long,layout=date,sep,default=2024-12-25"`
+		Windows  map[string]time.Duration `clapper:"long,default=a=1m"`
+	}
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	var defaults Foo
+	_, err := Parse(&defaults, "--nope")
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, defaults.Timeout)
+	assert.Equal(t, []time.Duration{time.Second}, defaults.Retries)
+	assert.Nil(t, defaults.Budget)
+	assert.True(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Equal(defaults.Since))
+	assert.Nil(t, defaults.Day)
+	assert.Equal(t, time.Unix(0, 0).UTC(), defaults.Epoch)
+	assert.True(t, time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC).Equal(defaults.Custom))
+	assert.Equal(t, []time.Time{date(2024, 12, 25)}, defaults.Holidays)
+	assert.Equal(t, map[string]time.Duration{"a": time.Minute}, defaults.Windows)
+
+	var foo Foo
+	_, err = Parse(&foo,
+		"--timeout", "1h30m", "--retries", "100ms", "2s", "--budget", "-5m", "--since", "2024-06-01T12:00:00+02:00",
+		"--day", "2024-02-29", "--epoch", "1709164800", "--holidays", "2024-01-01,2024-12-24", "--windows", "x=1h")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, foo.Timeout)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 2 * time.Second}, foo.Retries)
+	assert.Equal(t, ptr(-5*time.Minute), foo.Budget)
+	assert.True(t, time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC).Equal(foo.Since))
+	assert.Equal(t, ptr(date(2024, 2, 29)), foo.Day)
+	assert.Equal(t, date(2024, 2, 29), foo.Epoch)
+	assert.Equal(t, []time.Time{date(2024, 1, 1), date(2024, 12, 24)}, foo.Holidays)
+	assert.Equal(t, map[string]time.Duration{"x": time.Hour}, foo.Windows)
+}
+
+func TestDurationAndTimeInvalid(t *testing.T) {
+	type Foo struct {
+		Timeout time.Duration `clapper:"long,default=30s"`
+		Since   time.Time     `clapper:"long,default=2024-01-02T03:04:05Z"`
+		Day     time.Time     `clapper:"long,layout=date,default=2024-01-01"`
+		Epoch   time.Time     `clapper:"long,layout=unix,default=0"`
+		Custom  time.Time     `clapper:"long,layout=15:04,default=10:00"`
+	}
+
+	durationType := reflect.TypeOf(time.Duration(0))
+	timeType := reflect.TypeOf(time.Time{})
+	tests := []struct {
+		name string
+		args []string
+		err  error
+	}{
+		{
+			name: "duration without unit",
+			args: []string{"--timeout", "30"},
+			err:  NewUnexpectedInputFormatErrorExpecting("30", durationType, "duration like 30s or 1h30m"),
+		},
+		{
+			name: "time without zone",
+			args: []string{"--since", "2024-01-02T03:04:05"},
+			err:  NewUnexpectedInputFormatErrorExpecting("2024-01-02T03:04:05", timeType, "time like "+time.RFC3339),
+		},
+		{
+			name: "invalid date",
+			args: []string{"--day", "2024-02-30"},
+			err:  NewUnexpectedInputFormatErrorExpecting("2024-02-30", timeType, "date like 2006-01-02"),
+		},
+		{
+			name: "fractional timestamp",
+			args: []string{"--epoch", "1.5"},
+			err:  NewUnexpectedInputFormatErrorExpecting("1.5", timeType, "unix timestamp in seconds"),
+		},
+		{
+			name: "custom layout",
+			args: []string{"--custom", "10am"},
+			err:  NewUnexpectedInputFormatErrorExpecting("10am", timeType, "time in layout 15:04"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var foo Foo
+			_, err := Parse(&foo, test.args...)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+
+	type NoTime struct {
+		Timeout time.Duration `clapper:"long,layout=date"`
+	}
+	_, err := Parse(&NoTime{}, "--nope")
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrLayoutNoTime, 0, "Timeout", "long,layout=date", 6))
+
+	type NoLayout struct {
+		Since time.Time `clapper:"long,layout"`
+	}
+	_, err = Parse(&NoLayout{}, "--nope")
+	assert.ErrorIs(t, err, NewParseErrorAt(ErrNoLayoutValue, 0, "Since", "long,layout", 6))
+}
+
+func TestNetworkTypes(t *testing.T) {
+	type Foo struct {
+		Listen   netip.AddrPort        `clapper:"long,default=127.0.0.1:8080"`
+		Peer     netip.Addr            `clapper:"long,default=::1"`
+		Allow    []netip.Prefix        `clapper:"long,sep,default='10.0.0.0/8,fd00::/8'"`
+		Gateway  net.IP                `clapper:"long,default=10.0.0.1"`
+		DNS      []net.IP              `clapper:"long,default=1.1.1.1"`
+		Subnet   *net.IPNet            `clapper:"long"`
+		Endpoint *url.URL              `clapper:"long"`
+		Mirrors  []*url.URL            `clapper:"long,default=https://a.example.com"`
+		Routes   map[string]netip.Addr `clapper:"long,default=a=10.0.0.1"`
+		Command  string                `clapper:"command"`
+	}
+
+	var defaults Foo
+	_, err := Parse(&defaults, "--", "run")
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddrPort("127.0.0.1:8080"), defaults.Listen)
+	assert.Equal(t, netip.IPv6Loopback(), defaults.Peer)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")}, defaults.Allow)
+	assert.Equal(t, net.ParseIP("10.0.0.1"), defaults.Gateway)
+	assert.Equal(t, []net.IP{net.ParseIP("1.1.1.1")}, defaults.DNS)
+	assert.Nil(t, defaults.Subnet)
+	assert.Nil(t, defaults.Endpoint)
+	assert.Equal(t, "https://a.example.com", defaults.Mirrors[0].String())
+	assert.Equal(t, map[string]netip.Addr{"a": netip.MustParseAddr("10.0.0.1")}, defaults.Routes)
+
+	var foo Foo
+	_, err = Parse(&foo,
+		"--listen", "[::]:443", "--gateway", "fe80::1", "--dns", "8.8.8.8", "9.9.9.9", "--subnet", "192.168.1.7/24",
+		"--endpoint", "https://example.com:8443/api?v=2", "--mirrors", "https://b.example.com", "http://c.example.com",
+		"--", "run")
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddrPort("[::]:443"), foo.Listen)
+	assert.Equal(t, net.ParseIP("fe80::1"), foo.Gateway)
+	assert.Equal(t, []net.IP{net.ParseIP("8.8.8.8"), net.ParseIP("9.9.9.9")}, foo.DNS)
+	assert.Equal(t, "192.168.1.0/24", foo.Subnet.String())
+	assert.Equal(t, "example.com:8443", foo.Endpoint.Host)
+	assert.Equal(t, "2", foo.Endpoint.Query().Get("v"))
+	require.Len(t, foo.Mirrors, 2)
+	assert.Equal(t, "http://c.example.com", foo.Mirrors[1].String())
+	assert.Equal(t, "run", foo.Command)
+}
+
+func TestNetworkTypesInvalid(t *testing.T) {
+	type Foo struct {
+		Peer     netip.Addr     `clapper:"long,default=::1"`
+		Listen   netip.AddrPort `clapper:"long,default=127.0.0.1:80"`
+		Allow    netip.Prefix   `clapper:"long,default=10.0.0.0/8"`
+		Gateway  net.IP         `clapper:"long,default=10.0.0.1"`
+		Subnet   *net.IPNet     `clapper:"long"`
+		Endpoint *url.URL       `clapper:"long"`
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		msg  string
+	}{
+		{
+			name: "addr",
+			args: []string{"--peer", "10.0.0.300"},
+			msg:  "argument 2: unexpected input format. given '10.0.0.300', expected IPv4 or IPv6 address",
+		},
+		{
+			name: "addr port",
+			args: []string{"--listen", "10.0.0.1"},
+			msg: "argument 2: unexpected input format. given '10.0.0.1', " +
+				"expected IPv4 or IPv6 address with port like 10.0.0.1:80 or [::1]:80",
+		},
+		{
+			name: "prefix",
+			args: []string{"--allow", "10.0.0.0"},
+			msg:  "argument 2: unexpected input format. given '10.0.0.0', expected CIDR prefix like 10.0.0.0/8 or fd00::/8",
+		},
+		{
+			name: "ip",
+			args: []string{"--gateway", "gateway.local"},
+			msg:  "argument 2: unexpected input format. given 'gateway.local', expected IPv4 or IPv6 address",
+		},
+		{
+			name: "ip net",
+			args: []string{"--subnet", "10.0.0.0/33"},
+			msg:  "argument 2: unexpected input format. given '10.0.0.0/33', expected CIDR prefix like 10.0.0.0/8 or fd00::/8",
+		},
+		{
+			name: "url",
+			args: []string{"--endpoint", "http://[::1"},
+			msg:  "argument 2: unexpected input format. given 'http://[::1', expected URL like https://example.com/path",
+		},
+		{
+			name: "url without scheme",
+			args: []string{"--endpoint", "example.com"},
+			msg:  "argument 2: unexpected input format. given 'example.com', expected URL like https://example.com/path",
+		},
+		{
+			name: "url without host",
+			args: []string{"--endpoint", "file:///tmp"},
+			msg:  "argument 2: unexpected input format. given 'file:///tmp', expected URL like https://example.com/path",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var foo Foo
+			_, err := Parse(&foo, test.args...)
+			assert.EqualError(t, err, test.msg)
+		})
+	}
+}
+
+type logLevel int
+
+func (l *logLevel) UnmarshalText(text []byte) error {
+	levels := []string{"debug", "info", "warn"}
+	index := slices.Index(levels, string(text))
+	if index < 0 {
+		return fmt.Errorf("unknown level %q", text)
+	}
+	*l = logLevel(index)
+	return nil
+}
+
+type semver struct {
+	Major, Minor, Patch int
+}
+
+func (v *semver) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "v%d.%d.%d", &v.Major, &v.Minor, &v.Patch)
+	return err
+}
+
+// region implements both flag.Value and encoding.TextUnmarshaler, flag.Value is preferred.
+type region string
+
+func (r *region) String() string { return string(*r) }
+
+func (r *region) Set(value string) error {
+	*r = region(strings.ToUpper(value))
+	return nil
+}
+
+func (r *region) UnmarshalText(text []byte) error {
+	return errors.New("must not be used")
+}
+
+// csvList is a slice decoding itself, so it is a single value.
+type csvList []string
+
+func (c *csvList) String() string { return strings.Join(*c, ",") }
+
+func (c *csvList) Set(value string) error {
+	*c = strings.Split(value, ",")
+	return nil
+}
+
+// appendList collects the values of all occurrences of its flag.
+type appendList []string
+
+func (l *appendList) String() string { return strings.Join(*l, ",") }
+
+func (l *appendList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func TestFlagValueRepeated(t *testing.T) {
+	type Foo struct {
+		List   appendList  `clapper:"short=l,long"`
+		Region *region     `clapper:"long"`
+		More   *appendList `clapper:"long"`
+	}
+
+	var foo Foo
+	_, err := Parse(&foo, "-l", "x", "--list", "y", "-l", "z", "--region", "eu", "--region", "us", "--more=a")
+	require.NoError(t, err)
+	assert.Equal(t, appendList{"x", "y", "z"}, foo.List)
+	assert.Equal(t, ptr(region("US")), foo.Region)
+	assert.Equal(t, &appendList{"a"}, foo.More)
+
+	_, err = NewParser().WithRepeatPolicy(RepeatError).Parse(&Foo{}, "--list", "x", "--list", "y")
+	assert.ErrorIs(t, err, NewRepeatedFlagError("--list"))
+}
+
+func TestUnmarshalerTypes(t *testing.T) {
+	type Foo struct {
+		Level    logLevel            `clapper:"long,default=info"`
+		Levels   []logLevel          `clapper:"long,default=debug"`
+		Version  *semver             `clapper:"long"`
+		Region   region              `clapper:"long,default=eu"`
+		Regions  map[region]logLevel `clapper:"long,default=us=warn"`
+		Columns  csvList             `clapper:"long,default=a"`
+		Trailing []string            `clapper:"command"`
+	}
+
+	var defaults Foo
+	_, err := Parse(&defaults, "--", "run")
+	require.NoError(t, err)
+	assert.Equal(t, Foo{
+		Level:    1,
+		Levels:   []logLevel{0},
+		Region:   "EU",
+		Regions:  map[region]logLevel{"US": 2},
+		Columns:  csvList{"a"},
+		Trailing: []string{"run"},
+	}, defaults)
+
+	var foo Foo
+	_, err = Parse(&foo, "--levels", "warn", "info", "--version", "v1.2.3", "--region", "ap",
+		"--columns", "x,y", "run", "fast")
+	require.NoError(t, err)
+	assert.Equal(t, Foo{
+		Level:    1,
+		Levels:   []logLevel{2, 1},
+		Version:  &semver{Major: 1, Minor: 2, Patch: 3},
+		Region:   "AP",
+		Regions:  map[region]logLevel{"US": 2},
+		Columns:  csvList{"x", "y"},
+		Trailing: []string{"run", "fast"},
+	}, foo)
+
+	_, err = Parse(&foo, "--level", "loud")
+	assert.EqualError(t, err,
+		`argument 2: unexpected input format. given 'loud', expected clapper.logLevel (unknown level "loud")`)
+	assert.ErrorIs(t, err, NewUnexpectedInputFormatErrorExpecting("loud", reflect.TypeOf(logLevel(0)),
+		`clapper.logLevel (unknown level "loud")`))
+}
+
+// rgb and hostList stand for third-party types clapper does not know.
+type rgb struct {
+	R, G, B uint8
+}
+
+type hostList []string
+
+func parseRGB(input string) (rgb, error) {
+	switch input {
+	case "red":
+		return rgb{R: 255}, nil
+	case "green":
+		return rgb{G: 255}, nil
+	}
+	var color rgb
+	if _, err := fmt.Sscanf(input, "#%02x%02x%02x", &color.R, &color.G, &color.B); err != nil {
+		return rgb{}, errors.New("expected a color name or #rrggbb")
+	}
+	return color, nil
+}
+
+func TestRegisterConverter(t *testing.T) {
+	RegisterConverter(parseRGB).WithPlaceholder("COLOR").WithCompletions("red", "green")
+	RegisterConverter(func(input string) (hostList, error) {
+		return strings.Split(input, "+"), nil
+	})
+
+	type Foo struct {
+		Color   rgb            `clapper:"short=c,long,default=red,help=Text color"`
+		Palette []rgb          `clapper:"long,default=green"`
+		Accent  *rgb           `clapper:"long"`
+		Themes  map[string]rgb `clapper:"long,default=dark=#000000"`
+		Hosts   hostList       `clapper:"long,default=a"`
+		Command []string       `clapper:"command"`
+	}
+
+	var foo Foo
+	_, err := Parse(&foo, "-c", "#0a0b0c", "--palette", "red", "#ffffff", "--accent=green", "--hosts", "x+y", "run")
+	require.NoError(t, err)
+	assert.Equal(t, Foo{
+		Color:   rgb{R: 10, G: 11, B: 12},
+		Palette: []rgb{{R: 255}, {R: 255, G: 255, B: 255}},
+		Accent:  &rgb{G: 255},
+		Themes:  map[string]rgb{"dark": {}},
+		Hosts:   hostList{"x", "y"},
+		Command: []string{"run"},
+	}, foo)
+
+	_, err = Parse(&foo, "--color", "blue")
+	assert.EqualError(t, err,
+		"argument 2: unexpected input format. given 'blue', expected clapper.rgb (expected a color name or #rrggbb)")
+
+	help, err := HelpDefault(&Foo{})
+	require.NoError(t, err)
+	assert.Contains(t, help, "-c, --color COLOR (default: red)")
+	assert.Contains(t, help, "--palette COLOR   (default: green)")
+	assert.Contains(t, help, "--hosts           (default: a)")
+
+	completions := make(map[string][]string)
+	_, err = Help(&Foo{}, func(item *HelpItem, formatting *HelpFormatting) string {
+		completions[item.Invokation] = item.Completions
+		return ""
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"red", "green"}, completions["-c, --color"])
+	assert.Equal(t, []string{"red", "green"}, completions["--themes"])
+	assert.Empty(t, completions["--hosts"])
+}
+
+// onOff is a bool type with a converter, enabled bool is one decoding itself.
+type onOff bool
+
+type enabled bool
+
+func (e *enabled) UnmarshalText(text []byte) error {
+	*e = string(text) == "enabled"
+	return nil
+}
+
+func parseOnOff(input string) (onOff, error) {
+	switch input {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	return false, errors.New("expected on or off")
+}
+
+func TestConvertedBoolWithoutInput(t *testing.T) {
+	RegisterConverter(parseOnOff)
+
+	for _, typ := range []reflect.Type{reflect.TypeFor[onOff](), reflect.TypeFor[enabled]()} {
+		_, _, err := ValueFromString(typ, nil)
+		assert.ErrorIs(t, err, ErrEmptyArgument)
+	}
+}
+
+func TestConvertedBool(t *testing.T) {
+	RegisterConverter(parseOnOff)
+
+	type Foo struct {
+		Power   onOff   `clapper:"long,negatable,env=POWER,default=off"`
+		Enabled enabled `clapper:"long,default=disabled"`
+		Backup  *onOff  `clapper:"long"`
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		env  string
+		want Foo
+	}{
+		{name: "defaults", want: Foo{}},
+		{
+			name: "flags",
+			args: []string{"--power", "--enabled", "--backup"},
+			want: Foo{Power: true, Enabled: true, Backup: ptr(onOff(true))},
+		},
+		{
+			name: "assigned",
+			args: []string{"--power=on", "--enabled=enabled", "--backup=off"},
+			want: Foo{Power: true, Enabled: true, Backup: ptr(onOff(false))},
+		},
+		{name: "negated", args: []string{"--no-power=off"}, want: Foo{Power: true}},
+		{name: "env", env: "on", want: Foo{Power: true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.env != "" {
+				t.Setenv("POWER", test.env)
+			}
+			var foo Foo
+			_, err := Parse(&foo, test.args...)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, foo)
+		})
+	}
+
+	_, err := Parse(&Foo{}, "--power=true")
+	assert.EqualError(t, err,
+		"argument 1: unexpected input format. given 'true', expected clapper.onOff (expected on or off)")
+}
+
+func TestParserConverter(t *testing.T) {
+	type Foo struct {
+		Timeout time.Duration `clapper:"long,default=1"`
+	}
+
+	seconds := NewConverter(func(input string) (time.Duration, error) {
+		n, err := strconv.Atoi(input)
+		if err != nil {
+			return 0, NewUnexpectedInputFormatErrorExpecting(input, reflect.TypeOf(time.Duration(0)), "seconds")
+		}
+		return time.Duration(n) * time.Second, nil
+	}).WithPlaceholder("SECONDS")
+
+	var foo Foo
+	_, err := NewParser().WithConverter(seconds).Parse(&foo, "--timeout", "90")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, foo.Timeout)
+
+	_, err = NewParser().WithConverter(seconds).Parse(&foo, "--timeout", "1m")
+	assert.ErrorIs(t, err, NewUnexpectedInputFormatErrorExpecting("1m", reflect.TypeOf(time.Duration(0)), "seconds"))
+
+	help, err := NewParser().WithConverter(seconds).HelpDefault(&Foo{})
+	require.NoError(t, err)
+	assert.Contains(t, help, "--timeout SECONDS")
+
+	// Other parsers are not affected.
+	_, err = Parse(&foo, "--timeout", "1m")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, foo.Timeout)
+}
+
+type colonList []string
+
+func TestParserConverterSliceType(t *testing.T) {
+	type Foo struct {
+		List colonList `clapper:"long"`
+	}
+
+	colons := NewConverter(func(input string) (colonList, error) {
+		return strings.Split(input, ":"), nil
+	})
+
+	var foo Foo
+	trailing, err := NewParser().WithConverter(colons).Parse(&foo, "--list", "a:b", "c")
+	require.NoError(t, err)
+	assert.Equal(t, colonList{"a", "b"}, foo.List)
+	assert.Equal(t, []string{"c"}, trailing)
+
+	// Other parsers take the slice's values one by one.
+	trailing, err = Parse(&foo, "--list", "a:b", "c")
+	require.NoError(t, err)
+	assert.Equal(t, colonList{"a:b", "c"}, foo.List)
+	assert.Empty(t, trailing)
+}
@@ -0,0 +1,219 @@
+package clapper
+
+import (
+	"os"
+	"reflect"
+	"slices"
+)
+
+// ParseResult describes the outcome of parsing arguments into a (possibly nested) command struct.
+type ParseResult struct {
+	// Trailing holds all arguments which could not be assigned to the deepest selected command.
+	Trailing []string
+	// Path holds the names of all selected subcommands from the root downwards (iE `remote add` -> [remote, add]).
+	Path []string
+	// Command is a pointer to the deepest selected command struct or the root target if no subcommand was selected.
+	Command any
+}
+
+// commandLevel is a single struct within a subcommand tree together with its share of the arguments.
+type commandLevel struct {
+	path   []string
+	tags   ParsedTags
+	schema *Schema
+	// args holds the arguments of this level only.
+	args *ArgParserExt
+	// persistentArgs holds the arguments of this level and all levels below, which `persistent` fields are resolved from.
+	persistentArgs *ArgParserExt
+	// subcommandIndex is the struct field index of the selected subcommand or -1 if none was selected.
+	subcommandIndex int
+	// start is the index of the level's first argument within the arguments of all levels.
+	start int
+	// below are the levels of the selected subcommands below this one.
+	below []*commandLevel
+}
+
+// persistentArgsFor returns the arguments the `persistent` field with `tags` is resolved from. They end at the first
+// level below declaring a flag of the same name, as the field is shadowed from there on.
+func (l *commandLevel) persistentArgsFor(tags TagMap) *ArgParserExt {
+	for _, below := range l.below {
+		if shadows(below.tags, tags) {
+			return &ArgParserExt{Args: l.persistentArgs.Args[:below.start-l.start]}
+		}
+	}
+	return l.persistentArgs
+}
+
+// shadows returns true if any field of `parsedTags` declares a flag of the field with `tags`.
+func shadows(parsedTags ParsedTags, tags TagMap) bool {
+	refs := slices.Concat(flagRefsByPrecedence(tags)...)
+	for _, own := range parsedTags {
+		for _, ref := range slices.Concat(flagRefsByPrecedence(own)...) {
+			if slices.Contains(refs, ref) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parse parses `rawArgs` into the struct `value`. The result is returned even on errors, holding the path of the
+// command level which failed.
+func (p *Parser) parse(value reflect.Value, rawArgs []string) (*ParseResult, error) {
+	if len(rawArgs) == 0 {
+		rawArgs = os.Args[1:] // skip the first argument (program name)
+	}
+
+	result := &ParseResult{Path: make([]string, 0)}
+	levels, err := p.splitLevels(value.Type(), rawArgs, result)
+	if err != nil {
+		return result, err
+	}
+
+	if err = p.processLevels(value, levels, result); err != nil {
+		return result, err
+	}
+
+	if p.strict {
+		return result, checkStrict(levels, result)
+	}
+
+	return result, nil
+}
+
+// checkStrict fails on any unconsumed flag and on values given instead of a subcommand to a command which is not
+// runnable, suggesting the closest known names.
+func checkStrict(levels []*commandLevel, result *ParseResult) error {
+	// The root level's persistent arguments span the arguments of all levels.
+	if unknown := levels[0].persistentArgs.UnconsumedFlags(); len(unknown) > 0 {
+		return NewUnknownFlagError(unknown, suggestFlags(unknown, levels))
+	}
+
+	deepest := levels[len(levels)-1]
+	if _, runnable := result.Command.(Runner); !runnable && deepest.schema.HasSubcommands() && len(result.Trailing) > 0 {
+		name := result.Trailing[0]
+		return NewUnknownCommandError(name, suggest(name, deepest.schema.SubcommandNames())...)
+	}
+
+	return nil
+}
+
+// suggestFlags returns the closest known long flags of all levels for each of the unknown flags.
+func suggestFlags(unknown []string, levels []*commandLevel) map[string][]string {
+	known := make([]string, 0)
+	for _, level := range levels {
+		known = append(known, level.schema.LongNames()...)
+	}
+
+	suggestions := make(map[string][]string)
+	for _, flag := range unknown {
+		if NewArgType(flag) != ArgTypeLong {
+			continue
+		}
+		matches := suggest(ArgTypeLong.Value(flag), known)
+		for i := range matches {
+			matches[i] = ArgTypeLong.Prefix() + matches[i]
+		}
+		if len(matches) > 0 {
+			suggestions[flag] = matches
+		}
+	}
+	return suggestions
+}
+
+// splitLevels walks down the subcommand tree of `t` and splits the arguments at the names of the selected
+// subcommands. All levels share the same arguments so that `persistent` fields see the arguments of the levels below.
+func (p *Parser) splitLevels(t reflect.Type, rawArgs []string, result *ParseResult) ([]*commandLevel, error) {
+	levels := make([]*commandLevel, 0)
+	segments := make([][]string, 0)
+	// offsets hold the index of each segment's first argument within the raw arguments.
+	offsets := []int{0}
+	var parent *Schema
+	for {
+		parsedTags, err := p.structTags(t, result.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		schema := newSchema(t, parsedTags, p.conversion()).Inherit(parent)
+		levelArgs, subcommandIndex, rest, hasSubcommand := schema.SplitAtSubcommand(rawArgs)
+
+		level := &commandLevel{
+			path:            slices.Clone(result.Path),
+			tags:            parsedTags,
+			schema:          schema,
+			subcommandIndex: -1,
+		}
+		levels = append(levels, level)
+		segments = append(segments, levelArgs)
+
+		if !hasSubcommand {
+			break
+		}
+
+		level.subcommandIndex = subcommandIndex
+		result.Path = append(result.Path, parsedTags[subcommandIndex].SubcommandName())
+		t = t.Field(subcommandIndex).Type.Elem()
+		offsets = append(offsets, offsets[len(offsets)-1]+len(levelArgs)+1)
+		rawArgs = rest
+		parent = schema
+	}
+
+	all := make([]ArgValue, 0)
+	bounds := make([]int, 0, len(segments)+1)
+	for i, segment := range segments {
+		schema := levels[i].schema
+		sanitizer := NewSchemaArgumentSanitizer(segment, schema)
+		// Leading values are positional arguments of subcommands or, if there are subcommands, a mistyped subcommand.
+		if i > 0 || schema.HasSubcommands() {
+			sanitizer = NewSubcommandArgumentSanitizer(segment, schema)
+		}
+		bounds = append(bounds, len(all))
+		all = append(all, NewArgParserExtFrom(sanitizer, schema).shiftRawIndices(offsets[i]).Args...)
+	}
+	bounds = append(bounds, len(all))
+
+	// The levels' arguments are windows of `all`, so consuming an argument is visible on every level.
+	for i, level := range levels {
+		level.args = &ArgParserExt{Args: all[bounds[i]:bounds[i+1]], positionals: i > 0 || level.schema.HasSubcommands()}
+		level.persistentArgs = &ArgParserExt{Args: all[bounds[i]:]}
+		level.start = bounds[i]
+		level.below = levels[i+1:]
+	}
+
+	return levels, nil
+}
+
+// processLevels assigns the arguments of each level to its struct, allocating the selected subcommands on the way.
+func (p *Parser) processLevels(value reflect.Value, levels []*commandLevel, result *ParseResult) error {
+	for _, level := range levels {
+		result.Path = level.path
+
+		processor := NewStructFieldProcessor(value.Type(), value, level.tags, level.args).
+			withPersistentArgs(level.persistentArgsFor).
+			withOptions(p.fieldOptions())
+		for !processor.EOF() {
+			if err := processor.Next(); err != nil {
+				return err
+			}
+		}
+
+		if err := processor.Finalize(); err != nil {
+			return err
+		}
+
+		if level.subcommandIndex < 0 {
+			result.Trailing = processor.GetTrailing()
+			result.Command = value.Addr().Interface()
+			return nil
+		}
+
+		field := value.Field(level.subcommandIndex)
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		value = field.Elem()
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package clapper
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// conversion controls how values given as strings are converted to the types of fields.
+// The zero value converts like `ValueFromString()`.
+type conversion struct {
+	// literals accepts Go integer literals (iE `0x1F`, `0o755`, `0b1010` or `1_000_000`).
+	literals bool
+	// units accepts SI and IEC unit prefixes on integers (iE `10k` or `512Mi`).
+	units bool
+	// unit is the symbol which may follow the number and its prefix (iE `B` for `512MiB`).
+	unit string
+	// converters are the parser's converters taking precedence over the registered ones.
+	converters map[reflect.Type]*Converter
+	// layout of time values, which is a Go time layout or one of the named layouts `date` and `unix`.
+	// Defaults to RFC3339.
+	layout string
+}
+
+// Named layouts of the `layout` tag.
+const (
+	// layoutDate parses dates without time (iE `2024-02-29`).
+	layoutDate = "date"
+	// layoutUnix parses seconds since the unix epoch (iE `1709164800`).
+	layoutUnix = "unix"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// typeParser converts the input to a value of the type it is registered for.
+type typeParser func(c conversion, input string) (*reflect.Value, error)
+
+// typeParsers convert types which are no plain kinds. These types are single values, even if their kind is a slice
+// (iE net.IP).
+var typeParsers = map[reflect.Type]typeParser{
+	durationType:                  parseWith(time.ParseDuration, "duration like 30s or 1h30m"),
+	timeType:                      conversion.parseTime,
+	reflect.TypeFor[netip.Addr](): parseWith(netip.ParseAddr, "IPv4 or IPv6 address"),
+	reflect.TypeFor[netip.AddrPort](): parseWith(netip.ParseAddrPort,
+		"IPv4 or IPv6 address with port like 10.0.0.1:80 or [::1]:80"),
+	reflect.TypeFor[netip.Prefix](): parseWith(netip.ParsePrefix, "CIDR prefix like 10.0.0.0/8 or fd00::/8"),
+	reflect.TypeFor[net.IP]():       parseWith(parseIP, "IPv4 or IPv6 address"),
+	reflect.TypeFor[net.IPNet]():    parseWith(parseIPNet, "CIDR prefix like 10.0.0.0/8 or fd00::/8"),
+	reflect.TypeFor[url.URL]():      parseWith(parseURL, "URL like https://example.com/path"),
+}
+
+var (
+	flagValueType       = reflect.TypeFor[flag.Value]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// isValueType returns true for types which are converted as a whole by a Converter, a typeParser or by themselves.
+func (c conversion) isValueType(t reflect.Type) bool {
+	_, parsed := typeParsers[t]
+	_, converted := c.converterFor(t)
+	return parsed || converted || isUnmarshalerType(t)
+}
+
+// valueElemType returns the type of the single values of `t`, which is the element type of pointers, slices and maps.
+func (c conversion) valueElemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || c.isMultiValueType(t) {
+		t = t.Elem()
+	}
+	return t
+}
+
+// isUnmarshalerType returns true for types decoding themselves as flag.Value or encoding.TextUnmarshaler.
+func isUnmarshalerType(t reflect.Type) bool {
+	pointer := reflect.PointerTo(t)
+	return pointer.Implements(flagValueType) || pointer.Implements(textUnmarshalerType)
+}
+
+// isFlagValueType returns true for types decoded by their flag.Value implementation, which are all types implementing
+// it unless a Converter or a typeParser takes precedence.
+func (c conversion) isFlagValueType(t reflect.Type) bool {
+	_, parsed := typeParsers[t]
+	_, converted := c.converterFor(t)
+	return !parsed && !converted && reflect.PointerTo(t).Implements(flagValueType)
+}
+
+// unmarshal decodes the input with the flag.Value implementation of `t` or, if there is none, with its
+// encoding.TextUnmarshaler implementation. Errors are reported as UnexpectedInputFormatError including the reason.
+func unmarshal(t reflect.Type, input string) (*reflect.Value, error) {
+	target := reflect.New(t)
+	var err error
+	switch decoder := target.Interface().(type) {
+	case flag.Value:
+		err = decoder.Set(input)
+	case encoding.TextUnmarshaler:
+		err = decoder.UnmarshalText([]byte(input))
+	}
+	if err != nil {
+		return nil, newConversionError(input, t, err)
+	}
+	return ptr(target.Elem()), nil
+}
+
+// newConversionError returns an UnexpectedInputFormatError including the reason why the input could not be converted.
+func newConversionError(input string, t reflect.Type, reason error) UnexpectedInputFormatError {
+	return NewUnexpectedInputFormatErrorExpecting(input, t, fmt.Sprintf("%s (%s)", t, reason))
+}
+
+// parseWith returns a typeParser converting with `parse`. Its errors are reported as UnexpectedInputFormatError with
+// the `expected` description.
+func parseWith[T any](parse func(string) (T, error), expected string) typeParser {
+	return func(_ conversion, input string) (*reflect.Value, error) {
+		value, err := parse(input)
+		if err != nil {
+			return nil, NewUnexpectedInputFormatErrorExpecting(input, reflect.TypeFor[T](), expected)
+		}
+		return ptr(reflect.ValueOf(value)), nil
+	}
+}
+
+// unitPrefixes maps the SI and IEC unit prefixes accepted by the `units` tag to their multipliers.
+var unitPrefixes = map[string]uint64{
+	"k": 1e3, "K": 1e3, "M": 1e6, "G": 1e9, "T": 1e12, "P": 1e15, "E": 1e18,
+	"Ki": 1 << 10, "Mi": 1 << 20, "Gi": 1 << 30, "Ti": 1 << 40, "Pi": 1 << 50, "Ei": 1 << 60,
+}
+
+// base returns the base integers are parsed with. Base 0 accepts the prefixes and underscores of Go literals.
+func (c conversion) base() int {
+	if c.literals {
+		return 0
+	}
+	return 10
+}
+
+// splitUnit splits the input into the number and the multiplier of its unit prefix.
+// A number which is valid as it is never gets split (iE the hex literal `0x1E` is not `0x1` exa).
+func (c conversion) splitUnit(input string) (number string, multiplier uint64) {
+	if !c.units {
+		return input, 1
+	}
+	number = strings.TrimSuffix(input, c.unit)
+	if _, err := strconv.ParseInt(number, c.base(), 64); err == nil || errors.Is(err, strconv.ErrRange) {
+		return number, 1
+	}
+	for _, size := range []int{2, 1} {
+		if len(number) <= size {
+			continue
+		}
+		if multiplier, ok := unitPrefixes[number[len(number)-size:]]; ok {
+			return number[:len(number)-size], multiplier
+		}
+	}
+	return number, 1
+}
+
+// parseInteger parses the input as integer of the exact size of the integer type `t`.
+// Inputs exceeding the type, including negative inputs for unsigned types, are reported with the allowed range.
+func (c conversion) parseInteger(input string, t reflect.Type) (*reflect.Value, error) {
+	number, multiplier := c.splitUnit(input)
+	value := reflect.New(t).Elem()
+	if isUnsignedType(t) {
+		num, err := strconv.ParseUint(number, c.base(), 64)
+		if err != nil {
+			if _, signedErr := strconv.ParseInt(number, c.base(), 64); errors.Is(err, strconv.ErrRange) || signedErr == nil {
+				return nil, newIntegerRangeError(input, t)
+			}
+			return nil, c.integerFormatError(input, t)
+		}
+		if num > math.MaxUint64/multiplier || value.OverflowUint(num*multiplier) {
+			return nil, newIntegerRangeError(input, t)
+		}
+		value.SetUint(num * multiplier)
+		return &value, nil
+	}
+
+	num, err := strconv.ParseInt(number, c.base(), 64)
+	switch {
+	case errors.Is(err, strconv.ErrRange):
+		return nil, newIntegerRangeError(input, t)
+	case err != nil:
+		return nil, c.integerFormatError(input, t)
+	}
+	product := num * int64(multiplier)
+	if product/int64(multiplier) != num || value.OverflowInt(product) {
+		return nil, newIntegerRangeError(input, t)
+	}
+	value.SetInt(product)
+	return &value, nil
+}
+
+// integerFormatError returns an UnexpectedInputFormatError for input which is no integer.
+// With units accepted, the expected format is described with examples.
+func (c conversion) integerFormatError(input string, t reflect.Type) UnexpectedInputFormatError {
+	if !c.units {
+		return NewUnexpectedInputFormatError(input, t)
+	}
+	return NewUnexpectedInputFormatErrorExpecting(input, t,
+		fmt.Sprintf("%s with an optional unit prefix like 10k%s or 512Mi%s", t, c.unit, c.unit))
+}
+
+// parseTime parses the input in the conversion's layout. Dates and unix timestamps result in UTC times.
+func (c conversion) parseTime(input string) (*reflect.Value, error) {
+	var result time.Time
+	var err error
+	expected := "time in layout " + c.layout
+	switch c.layout {
+	case layoutUnix:
+		var seconds int64
+		seconds, err = strconv.ParseInt(input, 10, 64)
+		result = time.Unix(seconds, 0).UTC()
+		expected = "unix timestamp in seconds"
+	case layoutDate:
+		result, err = time.Parse(time.DateOnly, input)
+		expected = "date like " + time.DateOnly
+	case "":
+		result, err = time.Parse(time.RFC3339, input)
+		expected = "time like " + time.RFC3339
+	default:
+		result, err = time.Parse(c.layout, input)
+	}
+	if err != nil {
+		return nil, NewUnexpectedInputFormatErrorExpecting(input, timeType, expected)
+	}
+	return ptr(reflect.ValueOf(result)), nil
+}
+
+// parseIP parses an IPv4 or IPv6 address with `net.ParseIP()`.
+func parseIP(input string) (net.IP, error) {
+	ip := net.ParseIP(input)
+	if ip == nil {
+		return nil, &net.ParseError{Type: "IP address", Text: input}
+	}
+	return ip, nil
+}
+
+// parseIPNet parses a CIDR prefix with `net.ParseCIDR()`, dropping the host bits (iE `10.0.0.1/8` -> `10.0.0.0/8`).
+func parseIPNet(input string) (net.IPNet, error) {
+	_, network, err := net.ParseCIDR(input)
+	if err != nil {
+		return net.IPNet{}, err
+	}
+	return *network, nil
+}
+
+// parseURL parses an absolute URL with scheme and host with `url.ParseRequestURI()` (iE `https://example.com/path`).
+func parseURL(input string) (url.URL, error) {
+	parsed, err := url.ParseRequestURI(input)
+	if err != nil {
+		return url.URL{}, err
+	}
+	if !parsed.IsAbs() || parsed.Host == "" {
+		return url.URL{}, &url.Error{Op: "parse", URL: input, Err: errors.New("missing scheme or host")}
+	}
+	return *parsed, nil
+}
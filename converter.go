@@ -0,0 +1,113 @@
+package clapper
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// Converter converts command line values to a type clapper does not know, like types of third-party packages.
+// Create one with `NewConverter()` and register it with `RegisterConverter()` or `Parser.WithConverter()`.
+type Converter struct {
+	typ     reflect.Type
+	convert func(input string) (reflect.Value, error)
+	// placeholder names the value in the help (iE `--color COLOR`).
+	placeholder string
+	// completions are the candidates for completing the value.
+	completions []string
+}
+
+// NewConverter creates a Converter converting values to T with `convert`.
+// Errors of `convert` are reported as UnexpectedInputFormatError including the reason.
+func NewConverter[T any](convert func(input string) (T, error)) *Converter {
+	return &Converter{
+		typ: reflect.TypeFor[T](),
+		convert: func(input string) (reflect.Value, error) {
+			value, err := convert(input)
+			return reflect.ValueOf(&value).Elem(), err
+		},
+	}
+}
+
+// WithPlaceholder sets the name of the value shown after the flags of this type in the help (iE `--color COLOR`).
+func (c *Converter) WithPlaceholder(name string) *Converter {
+	c.placeholder = name
+	return c
+}
+
+// WithCompletions sets the candidates for completing values of this type. They are provided as `Completions` of the
+// HelpItems of the flags.
+func (c *Converter) WithCompletions(candidates ...string) *Converter {
+	c.completions = candidates
+	return c
+}
+
+// valueFromString converts the input with the converter.
+func (c *Converter) valueFromString(input string) (*reflect.Value, error) {
+	value, err := c.convert(input)
+	if err != nil {
+		if errors.As(err, &UnexpectedInputFormatError{}) {
+			return nil, err
+		}
+		return nil, newConversionError(input, c.typ, err)
+	}
+	return &value, nil
+}
+
+// converters holds the converters registered by `RegisterConverter()`.
+var converters = struct {
+	sync.RWMutex
+	byType map[reflect.Type]*Converter
+}{byType: make(map[reflect.Type]*Converter)}
+
+// RegisterConverter creates a Converter converting values to T with `convert` and registers it for all parsers.
+// It takes precedence over the built-in conversion of T. A slice or map type with a converter is a single value.
+// The returned Converter can be refined with a placeholder and completions.
+// Converters should be registered during initialization, before any parsing.
+func RegisterConverter[T any](convert func(input string) (T, error)) *Converter {
+	converter := NewConverter(convert)
+	converters.Lock()
+	defer converters.Unlock()
+	converters.byType[converter.typ] = converter
+	return converter
+}
+
+// registeredConverter returns the converter registered by `RegisterConverter()` for `t`.
+func registeredConverter(t reflect.Type) (*Converter, bool) {
+	converters.RLock()
+	defer converters.RUnlock()
+	converter, ok := converters.byType[t]
+	return converter, ok
+}
+
+// WithConverter adds a converter which is only used by this parser. It takes precedence over converters registered
+// by `RegisterConverter()` for the same type.
+func (p *Parser) WithConverter(converter *Converter) *Parser {
+	if p.converters == nil {
+		p.converters = make(map[reflect.Type]*Converter)
+	}
+	p.converters[converter.typ] = converter
+	return p
+}
+
+// converterFor returns the parser's converter for `t` or the registered one.
+func (c conversion) converterFor(t reflect.Type) (*Converter, bool) {
+	if converter, ok := c.converters[t]; ok {
+		return converter, true
+	}
+	return registeredConverter(t)
+}
+
+// withConverter adds the placeholder and completions of the converter of `t` to the HelpItem, if there is one.
+func (c conversion) withConverter(item *HelpItem, t reflect.Type) *HelpItem {
+	converter, ok := c.converterFor(c.valueElemType(t))
+	if !ok {
+		return item
+	}
+	if converter.placeholder != "" {
+		item.Placeholder = ptr(converter.placeholder)
+	}
+	item.Completions = slices.Clone(converter.completions)
+	return item
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 var (
@@ -12,6 +13,14 @@ var (
 	_ error = UnknownTagTypeError{}
 	_ error = UnexpectedInputFormatError{}
 	_ error = CommandRequiredError{}
+	_ error = UnknownCommandError{}
+	_ error = UsageError{}
+	_ error = NotRunnableError{}
+	_ error = UnknownFlagError{}
+	_ error = RepeatedFlagError{}
+	_ error = TooFewValuesError{}
+	_ error = DuplicateKeyError{}
+	_ error = ArgumentError{}
 
 	ErrNoStruct                        = errors.New("target is not a struct")
 	ErrEmptyArgument                   = errors.New("empty argument")
@@ -23,6 +32,26 @@ var (
 	ErrCommandCanNotHaveValue          = errors.New("command can't have a value")
 	ErrDuplicateCommandTag             = errors.New("duplicate command tag found")
 	ErrNoDefaultValue                  = errors.New("default spcified but no default value given")
+	ErrInvalidEnvName                  = errors.New("environment variable name must not contain '=' or spaces")
+	ErrSubcommandNameIsFlag            = errors.New("subcommand name can't start with a dash")
+	ErrSubcommandNoStructPointer       = errors.New("subcommand must be a pointer to a struct")
+	ErrSubcommandWithInput             = errors.New("subcommand can only be combined with help")
+	ErrDuplicateSubcommand             = errors.New("duplicate subcommand name found")
+	ErrCommandWithSubcommands          = errors.New("command tag can't be combined with subcommands")
+	ErrPersistentCanNotHaveValue       = errors.New("persistent can't have a value")
+	ErrPersistentWithoutFlag           = errors.New("persistent requires short or long")
+	ErrCountCanNotHaveValue            = errors.New("count can't have a value")
+	ErrCountNoInteger                  = errors.New("count requires an integer field with short or long")
+	ErrNegatableCanNotHaveValue        = errors.New("negatable can't have a value")
+	ErrInvalidNargs                    = errors.New("nargs must be a positive count like 2 or a range like 1..3")
+	ErrNargsNoSlice                    = errors.New("nargs requires a slice or map field with short or long")
+	ErrUnterminatedQuote               = errors.New("unterminated quote")
+	ErrUnexpectedAfterQuote            = errors.New("unexpected character after quoted value")
+	ErrSepNoSlice                      = errors.New("sep requires a slice or map field")
+	ErrNegatableNoBool                 = errors.New("negatable requires a bool or *bool field with long")
+	ErrUnitsNoInteger                  = errors.New("units requires an integer field or a slice or map of integers")
+	ErrNoLayoutValue                   = errors.New("layout specified but no layout given")
+	ErrLayoutNoTime                    = errors.New("layout requires a time.Time field or a slice or map of them")
 )
 
 // CommandRequiredError will be thrown when a command tag is required but no command is provided.
@@ -44,11 +73,103 @@ func (e CommandRequiredError) Error() string {
 	return result
 }
 
+// UnknownCommandError will be thrown when a subcommand is requested which is not defined.
+type UnknownCommandError struct {
+	Name string
+	// Suggestions holds the closest known subcommand names, if there are any.
+	Suggestions []string
+}
+
+func NewUnknownCommandError(name string, suggestions ...string) UnknownCommandError {
+	return UnknownCommandError{Name: name, Suggestions: suggestions}
+}
+
+func (e UnknownCommandError) Error() string {
+	result := fmt.Sprintf("unknown command '%s'", e.Name)
+	if len(e.Suggestions) > 0 {
+		result += fmt.Sprintf(", did you mean '%s'?", strings.Join(e.Suggestions, "' or '"))
+	}
+	return result
+}
+
+// UnknownFlagError will be thrown in strict mode when flags are given which are not known to the target struct.
+type UnknownFlagError struct {
+	// Flags holds all unknown flags as given on the command line (iE `--dyr-run`).
+	Flags []string
+	// Suggestions holds the closest known flags for each unknown flag, if there are any (iE `--dyr-run` -> [--dry-run]).
+	Suggestions map[string][]string
+}
+
+func NewUnknownFlagError(flags []string, suggestions map[string][]string) UnknownFlagError {
+	return UnknownFlagError{Flags: flags, Suggestions: suggestions}
+}
+
+func (e UnknownFlagError) Error() string {
+	if len(e.Flags) == 1 {
+		return "unknown flag " + e.describe(e.Flags[0], ", did you mean %s?")
+	}
+
+	flags := make([]string, 0, len(e.Flags))
+	for _, flag := range e.Flags {
+		flags = append(flags, e.describe(flag, " (did you mean %s?)"))
+	}
+	return fmt.Sprintf("unknown flags %s", strings.Join(flags, ", "))
+}
+
+// describe returns the flag followed by its suggestions formatted with `format`, if there are any.
+func (e UnknownFlagError) describe(flag string, format string) string {
+	suggestions := e.Suggestions[flag]
+	if len(suggestions) == 0 {
+		return flag
+	}
+	return flag + fmt.Sprintf(format, strings.Join(suggestions, " or "))
+}
+
+// UsageError will be returned by `Execute()` when the command line input is invalid.
+// It holds the path of the affected command and its help to be displayed to the user.
+type UsageError struct {
+	error
+	Path []string
+	Help string
+}
+
+func NewUsageError(from error, path []string, help string) UsageError {
+	return UsageError{
+		error: from,
+		Path:  path,
+		Help:  help,
+	}
+}
+
+func (e UsageError) Underlying() error {
+	return e.error
+}
+
+func (e UsageError) Unwrap() error {
+	return e.error
+}
+
+// NotRunnableError will be returned by `Execute()` when the selected command does neither implement Runner nor has
+// any subcommands.
+type NotRunnableError struct {
+	Type string
+}
+
+func NewNotRunnableError(t string) NotRunnableError {
+	return NotRunnableError{Type: t}
+}
+
+func (e NotRunnableError) Error() string {
+	return fmt.Sprintf("command '%s' does not implement Runner", e.Type)
+}
+
 // UnsupportedReflectTypeError will be thrown when a struct field has a type that can not be set with the provided value.
 // For example givving a string to a field of type int.
 type UnexpectedInputFormatError struct {
 	Input          string
 	ExpectedFormat reflect.Type
+	// Expected describes the accepted input in more detail than the type (iE `int8 between -128 and 127`), if set.
+	Expected string
 }
 
 func NewUnexpectedInputFormatError(input string, expected reflect.Type) UnexpectedInputFormatError {
@@ -58,7 +179,19 @@ func NewUnexpectedInputFormatError(input string, expected reflect.Type) Unexpect
 	}
 }
 
+// NewUnexpectedInputFormatErrorExpecting creates an UnexpectedInputFormatError describing the accepted input.
+func NewUnexpectedInputFormatErrorExpecting(input string, expected reflect.Type, description string) UnexpectedInputFormatError {
+	return UnexpectedInputFormatError{
+		Input:          input,
+		ExpectedFormat: expected,
+		Expected:       description,
+	}
+}
+
 func (e UnexpectedInputFormatError) Error() string {
+	if e.Expected != "" {
+		return fmt.Sprintf("unexpected input format. given '%s', expected %s", e.Input, e.Expected)
+	}
 	return fmt.Sprintf("unexpected input format. given '%s', expected %s", e.Input, e.ExpectedFormat)
 }
 
@@ -94,14 +227,22 @@ type ParseError struct {
 	Index   int
 	Name    string
 	TagLine string
+	// Column is the 1-based position within the tag line the error refers to or 0 if unknown.
+	Column int
 }
 
 func NewParseError(from error, index int, name string, tagLine string) ParseError {
+	return NewParseErrorAt(from, index, name, tagLine, 0)
+}
+
+// NewParseErrorAt creates a ParseError referring to the given column of the tag line.
+func NewParseErrorAt(from error, index int, name string, tagLine string, column int) ParseError {
 	return ParseError{
 		error:   from,
 		Index:   index,
 		Name:    name,
 		TagLine: tagLine,
+		Column:  column,
 	}
 }
 
@@ -110,7 +251,11 @@ func (e ParseError) Underlying() error {
 }
 
 func (e ParseError) Error() string {
-	return fmt.Sprintf("parse error '%s' at index %d: field '%s' (tag-line: %s)", e.error, e.Index, e.Name, e.TagLine)
+	position := fmt.Sprintf("index %d", e.Index)
+	if e.Column > 0 {
+		position += fmt.Sprintf(", column %d", e.Column)
+	}
+	return fmt.Sprintf("parse error '%s' at %s: field '%s' (tag-line: %s)", e.error, position, e.Name, e.TagLine)
 }
 
 // UnknownTagTypeError will be thrown when a struct field type is unsupported by claper.
@@ -125,3 +270,79 @@ func NewUnsupportedReflectTypeError(t string) UnsupportedReflectTypeError {
 func (e UnsupportedReflectTypeError) Error() string {
 	return "unsupported reflect type:" + e.Type
 }
+
+// RepeatedFlagError will be thrown if a non-slice flag is given several times and the parser's RepeatPolicy is
+// RepeatError.
+type RepeatedFlagError struct {
+	// Flag is the repeated flag as given on the command line (iE `--color`).
+	Flag string
+}
+
+func NewRepeatedFlagError(flag string) RepeatedFlagError {
+	return RepeatedFlagError{Flag: flag}
+}
+
+func (e RepeatedFlagError) Error() string {
+	return fmt.Sprintf("flag %s given more than once", e.Flag)
+}
+
+// TooFewValuesError will be thrown if an occurrence of a flag with `nargs` is followed by less values than required.
+type TooFewValuesError struct {
+	// Flag is the flag as given on the command line (iE `--pair`).
+	Flag     string
+	Required int
+	Given    int
+}
+
+func NewTooFewValuesError(flag string, required int, given int) TooFewValuesError {
+	return TooFewValuesError{Flag: flag, Required: required, Given: given}
+}
+
+func (e TooFewValuesError) Error() string {
+	values := "values"
+	if e.Required == 1 {
+		values = "value"
+	}
+	return fmt.Sprintf("flag %s requires at least %d %s but got %d", e.Flag, e.Required, values, e.Given)
+}
+
+// DuplicateKeyError will be thrown if a key of a map field is given several times and the parser's RepeatPolicy is
+// RepeatError.
+type DuplicateKeyError struct {
+	Key string
+}
+
+func NewDuplicateKeyError(key string) DuplicateKeyError {
+	return DuplicateKeyError{Key: key}
+}
+
+func (e DuplicateKeyError) Error() string {
+	return fmt.Sprintf("key %s given more than once", e.Key)
+}
+
+// ArgumentError wraps an error caused by a command line argument with the argument's position.
+type ArgumentError struct {
+	error
+	// Position is the 1-based position of the raw argument on the command line (like `$1` in a shell) or 0 if unknown.
+	Position int
+}
+
+// NewArgumentError wraps `from` with the position of the raw argument `arg` originates from.
+func NewArgumentError(from error, arg ArgValue) ArgumentError {
+	return ArgumentError{error: from, Position: arg.RawIndex + 1}
+}
+
+func (e ArgumentError) Underlying() error {
+	return e.error
+}
+
+func (e ArgumentError) Unwrap() error {
+	return e.error
+}
+
+func (e ArgumentError) Error() string {
+	if e.Position <= 0 {
+		return e.error.Error()
+	}
+	return fmt.Sprintf("argument %d: %s", e.Position, e.error)
+}
@@ -1,51 +1,40 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"github.com/mittwald/clapper"
 )
 
-type Config struct {
-	Help    bool   `clapper:"short,long,help='Display help message"`
-	Command string `clapper:"command,help=say|sing <message>"`
+type SayCommand struct {
+	Loud bool `clapper:"short,long,help=Say it loud"`
 }
 
-// invoke like `go run ./example/command/main.go -- sing hello world `
-func main() {
-	var config Config
-	trailing, err := clapper.Parse(&config)
-	if err != nil {
-		config.Help = true
+func (c *SayCommand) Run(_ context.Context, trailing []string) error {
+	message := strings.Join(trailing, " ")
+	if c.Loud {
+		message = strings.ToUpper(message)
 	}
+	fmt.Printf("Saying: %s\n", message)
+	return nil
+}
 
-	if err == nil && len(trailing) == 0 {
-		config.Help = true
-		fmt.Println("Missing command.")
-	}
+type SingCommand struct{}
 
-	if config.Help {
-		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-		}
-		fmt.Println("Usage: sample [options] command")
-		help, err := clapper.HelpDefault(&config)
-		if err != nil {
-			panic(err)
-		}
-		fmt.Println(help)
-		return
-	}
+func (c *SingCommand) Run(_ context.Context, trailing []string) error {
+	fmt.Printf("Singing: %s\n", strings.Join(trailing, " "))
+	return nil
+}
 
-	message := strings.Join(trailing, " ")
-	// do what we are supposed to do with the command
-	switch config.Command {
-	case "say":
-		fmt.Printf("Saying: %s\n", message)
-	case "sing":
-		fmt.Printf("Singing: %s\n", message)
-	default:
-		fmt.Printf("Unknown command: %s\n", config.Command)
-	}
+type Config struct {
+	Say  *SayCommand  `clapper:"subcommand,help=Say the message"`
+	Sing *SingCommand `clapper:"subcommand,help=Sing the message"`
+}
+
+// invoke like `go run ./example/command/main.go say --loud hello world`
+func main() {
+	var config Config
+	clapper.ExecuteAndExit(context.Background(), &config)
 }
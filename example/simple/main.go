@@ -7,7 +7,7 @@ import (
 )
 
 type Config struct {
-	Help    bool    `clapper:"short,long,help='Display help message"`
+	Help    bool    `clapper:"short,long,help='Display help message'"`
 	Version bool    `clapper:"short,long,help='Display version information'"`
 	Debug   bool    `clapper:"short,long,help='Enable debug mode'"`
 	Server  string  `clapper:"short,long,default='localhost:8080',help='Server to connect to'"`
@@ -15,7 +15,7 @@ type Config struct {
 	Pass    *string `clapper:"short,long,help='Password will be used for authentication'"`
 }
 
-// invoke like `go run ./example/simple/main.go -- --user foo --server some-server doit`
+// invoke like `go run ./example/simple --user foo --server some-server doit`
 func main() {
 	var config Config
 	trailing, err := clapper.Parse(&config)
@@ -6,10 +6,14 @@ import (
 )
 
 type StructFieldProcessor struct {
-	targetType   reflect.Type
-	targetValue  reflect.Value
-	tags         ParsedTags
-	args         *ArgParserExt
+	targetType  reflect.Type
+	targetValue reflect.Value
+	tags        ParsedTags
+	args        *ArgParserExt
+	// persistentArgs returns the arguments taken for a `persistent` field instead of args if set.
+	persistentArgs func(tags TagMap) *ArgParserExt
+	// opts are the parser's options for setting fields.
+	opts         fieldOptions
 	currentIndex int
 	commandHelp  string
 	commandIndex *int
@@ -27,6 +31,18 @@ func NewStructFieldProcessor(target reflect.Type, value reflect.Value, tags Pars
 	}
 }
 
+// withOptions sets the parser's options for setting fields.
+func (f *StructFieldProcessor) withOptions(opts fieldOptions) *StructFieldProcessor {
+	f.opts = opts
+	return f
+}
+
+// withPersistentArgs sets the function returning the arguments a `persistent` field is resolved from.
+func (f *StructFieldProcessor) withPersistentArgs(args func(tags TagMap) *ArgParserExt) *StructFieldProcessor {
+	f.persistentArgs = args
+	return f
+}
+
 func (f *StructFieldProcessor) EOF() bool {
 	return f.currentIndex >= f.targetType.NumField()
 }
@@ -40,7 +56,7 @@ func (f *StructFieldProcessor) Next() error {
 	f.currentIndex++
 
 	tags, hasTags := f.tags[index]
-	if !hasTags {
+	if !hasTags || tags.IsSubcommand() {
 		return nil
 	}
 
@@ -55,7 +71,12 @@ func (f *StructFieldProcessor) Next() error {
 	field := f.targetType.Field(index)
 	fieldValue := f.targetValue.Field(index)
 
-	return trySetFieldConsumingArgs(field, fieldValue, tags, f.args)
+	args := f.args
+	if tags.HasTagType(TagPersistent) && f.persistentArgs != nil {
+		args = f.persistentArgs(tags)
+	}
+
+	return trySetFieldConsumingArgs(field, fieldValue, tags, args, f.opts)
 }
 
 func (f *StructFieldProcessor) HasCommand() bool {
@@ -71,9 +92,13 @@ func (f *StructFieldProcessor) ProcessCommand() error {
 	field := f.targetType.Field(*f.commandIndex)
 	fieldValue := f.targetValue.Field(*f.commandIndex)
 
-	took, err := StringReflect(field, fieldValue, trailing)
+	took, err := f.opts.conversion(f.tags[*f.commandIndex]).stringReflect(field, fieldValue, trailing)
 	if err != nil {
-		return err
+		given := make([]*ArgValue, 0, len(trailing))
+		for _, index := range f.args.trailingIndices() {
+			given = append(given, &f.args.Args[index])
+		}
+		return argumentErrorAt(err, given)
 	}
 
 	f.args.ConsumeTrailing(took)
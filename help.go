@@ -3,6 +3,7 @@ package clapper
 import (
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 )
 
@@ -18,8 +19,8 @@ func DefaultHelpFormatting() *HelpFormatting {
 }
 
 func (h *HelpFormatting) Update(item *HelpItem) *HelpFormatting {
-	if h.InvokationMax < len(item.Invokation) {
-		h.InvokationMax = len(item.Invokation)
+	if invokation := item.invokationColumn(); h.InvokationMax < len(invokation) {
+		h.InvokationMax = len(invokation)
 	}
 	if item.Default != nil {
 		if h.DefaultMax < len(*item.Default) {
@@ -31,12 +32,30 @@ func (h *HelpFormatting) Update(item *HelpItem) *HelpFormatting {
 
 type HelpItem struct {
 	Invokation string
-	Default    *string
-	Help       *string
+	// Placeholder names the flag's value (iE `COLOR` for `--color COLOR`), if its type's Converter declares one.
+	Placeholder *string
+	// Env is the name of the environment variable the flag falls back to.
+	Env     *string
+	Default *string
+	Help    *string
+	// Completions are the candidates for completing the flag's value, if its type's Converter declares them.
+	Completions []string
 }
 
-func (h *HelpItem) Display(formatting HelpFormatting) string {
+// invokationColumn returns the invokation followed by the placeholder and the environment variable if there are any.
+func (h *HelpItem) invokationColumn() string {
 	result := h.Invokation
+	if h.Placeholder != nil {
+		result += " " + *h.Placeholder
+	}
+	if h.Env == nil {
+		return result
+	}
+	return fmt.Sprintf("%s [$%s]", result, *h.Env)
+}
+
+func (h *HelpItem) Display(formatting HelpFormatting) string {
+	result := h.invokationColumn()
 	result += strings.Repeat(" ", formatting.InvokationMax-len(result))
 	def := ""
 	if h.Default != nil {
@@ -71,6 +90,7 @@ func UsageHelp(tags ParsedTags) (string, bool) {
 // HelpItemFromTags creates a HelpItem from the given tags or retruns nil if the tags represent an informational tag line only.
 func HelpItemFromTags(tags TagMap) *HelpItem {
 	invoke := ""
+	var env *string
 	var def *string
 	var help *string
 	if !tags.HasInputTag() {
@@ -93,6 +113,12 @@ func HelpItemFromTags(tags TagMap) *HelpItem {
 		}
 		invoke += "--" + name
 	}
+	if negation, ok := tags.NegationName(); ok {
+		invoke += ", --" + negation
+	}
+	if envTag, ok := tags[TagEnv]; ok {
+		env = ptr(envTag.ArgumentName())
+	}
 	defaultTag, ok := tags[TagDefault]
 	if ok {
 		def = ptr(fmt.Sprintf("(default: %s)", defaultTag.Value))
@@ -104,11 +130,24 @@ func HelpItemFromTags(tags TagMap) *HelpItem {
 
 	return &HelpItem{
 		Invokation: invoke,
+		Env:        env,
 		Default:    def,
 		Help:       help,
 	}
 }
 
+// subcommandHelpItem creates a HelpItem for a subcommand or returns nil if the tags represent no subcommand.
+func subcommandHelpItem(tags TagMap) *HelpItem {
+	if !tags.IsSubcommand() {
+		return nil
+	}
+	item := &HelpItem{Invokation: tags.SubcommandName()}
+	if helpTag, ok := tags[TagHelp]; ok {
+		item.Help = &helpTag.Value
+	}
+	return item
+}
+
 func DefaultHelpFormatter(item *HelpItem, formatting *HelpFormatting) string {
 	return item.Display(*formatting)
 }
@@ -118,33 +157,137 @@ func HelpDefault[T any](target *T) (string, error) {
 }
 
 func Help[T any](target *T, formatter FormatterFn) (string, error) {
-	t := reflect.TypeOf(*target)
-	if t.Kind() != reflect.Struct {
+	return NewParser().Help(target, formatter)
+}
+
+// HelpCommand returns the help of the subcommand reached by `path` (iE `remote`, `add`) below `target`.
+func HelpCommand[T any](target *T, formatter FormatterFn, path ...string) (string, error) {
+	return NewParser().HelpCommand(target, formatter, path...)
+}
+
+// HelpDefault works like the package level `HelpDefault()` but respects the parser's options.
+func (p *Parser) HelpDefault(target any) (string, error) {
+	return p.Help(target, DefaultHelpFormatter)
+}
+
+// Help works like the package level `Help()` but respects the parser's options. `target` must be a pointer to a struct.
+func (p *Parser) Help(target any, formatter FormatterFn) (string, error) {
+	return p.HelpCommand(target, formatter)
+}
+
+// HelpCommand works like the package level `HelpCommand()` but respects the parser's options.
+// `target` must be a pointer to a struct.
+func (p *Parser) HelpCommand(target any, formatter FormatterFn, path ...string) (string, error) {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
 		return "", ErrNoStruct
 	}
 
-	parsedTags, err := parseStructTags(t)
+	t := value.Elem().Type()
+	parsedTags, err := p.structTags(t, nil)
 	if err != nil {
 		return "", err
 	}
 
+	type inheritedFlag struct {
+		tags TagMap
+		item *HelpItem
+	}
+	inherited := make([]inheritedFlag, 0)
+	for i, name := range path {
+		schema := newSchema(t, parsedTags, p.conversion())
+		index, ok := schema.Subcommand(name)
+		if !ok {
+			return "", NewUnknownCommandError(name, suggest(name, schema.SubcommandNames())...)
+		}
+		for _, tags := range sortedTags(parsedTags) {
+			if tags.HasTagType(TagPersistent) {
+				inherited = append(inherited, inheritedFlag{tags: tags, item: p.flagHelpItem(t, tags)})
+			}
+		}
+		t = t.Field(index).Type.Elem()
+		if parsedTags, err = p.structTags(t, path[:i+1]); err != nil {
+			return "", err
+		}
+		// Flags shadowed by a flag of the same name are not inherited any further.
+		inherited = slices.DeleteFunc(inherited, func(flag inheritedFlag) bool {
+			return shadows(parsedTags, flag.tags)
+		})
+	}
+
+	items := make([]*HelpItem, 0, len(inherited))
+	for _, flag := range inherited {
+		items = append(items, flag.item)
+	}
+	return p.helpFor(t, parsedTags, items, formatter), nil
+}
+
+// flagHelpItem creates the HelpItem of a flag of the struct `t` or returns nil if the tags represent no flag.
+// The placeholder and completions of the Converter of the flag's type are taken over.
+func (p *Parser) flagHelpItem(t reflect.Type, tags TagMap) *HelpItem {
+	item := HelpItemFromTags(tags)
+	if item == nil {
+		return nil
+	}
+	return p.fieldOptions().conversion(tags).withConverter(item, t.Field(tags.FieldIndex()).Type)
+}
+
+// sortedTags returns the TagMaps of all tagged fields in the order of their declaration.
+func sortedTags(parsedTags ParsedTags) []TagMap {
+	indices := make([]int, 0, len(parsedTags))
+	for index := range parsedTags {
+		indices = append(indices, index)
+	}
+	slices.Sort(indices)
+
+	result := make([]TagMap, 0, len(indices))
+	for _, index := range indices {
+		result = append(result, parsedTags[index])
+	}
+	return result
+}
+
+// helpFor renders the help of the command level `t`. `inherited` are the persistent flags of all parent commands.
+func (p *Parser) helpFor(t reflect.Type, parsedTags ParsedTags, inherited []*HelpItem, formatter FormatterFn) string {
 	help := ""
 	if usageHelp, ok := UsageHelp(parsedTags); ok {
 		help = usageHelp + "\n"
 	}
 
-	formatting := DefaultHelpFormatting()
-	helpItems := make([]*HelpItem, 0, len(parsedTags))
-	for _, tags := range parsedTags {
-		if item := HelpItemFromTags(tags); item != nil {
-			helpItems = append(helpItems, item)
-			formatting.Update(item)
+	flags := make([]*HelpItem, 0, len(parsedTags))
+	commands := make([]*HelpItem, 0)
+	for _, tags := range sortedTags(parsedTags) {
+		if item := p.flagHelpItem(t, tags); item != nil {
+			flags = append(flags, item)
 		}
+		if item := subcommandHelpItem(tags); item != nil {
+			commands = append(commands, item)
+		}
+	}
+	help += formatHelpItems(flags, formatter)
+
+	if flags := formatHelpItems(inherited, formatter); flags != "" {
+		help += "Inherited flags:\n" + flags
+	}
+
+	if commands := formatHelpItems(commands, formatter); commands != "" {
+		help += "Commands:\n" + commands
 	}
 
+	return help
+}
+
+// formatHelpItems formats the HelpItems with a shared formatting.
+func formatHelpItems(helpItems []*HelpItem, formatter FormatterFn) string {
+	formatting := DefaultHelpFormatting()
+	for _, item := range helpItems {
+		formatting.Update(item)
+	}
+
+	help := ""
 	for _, h := range helpItems {
 		help += formatter(h, formatting) + "\n"
 	}
 
-	return help, nil
+	return help
 }
@@ -0,0 +1,127 @@
+package clapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+const (
+	// ExitCodeError is returned by `ExitCode()` for errors returned by a command's `Run`.
+	ExitCodeError = 1
+	// ExitCodeUsage is returned by `ExitCode()` for invalid command line input.
+	ExitCodeUsage = 2
+)
+
+// Runner is implemented by command structs which can be invoked by `Execute()`.
+type Runner interface {
+	// Run executes the command with all arguments which could not be assigned to the command struct.
+	Run(ctx context.Context, trailing []string) error
+}
+
+// ExitCoder can be implemented by errors returned from `Run` to control the exit code of `ExecuteAndExit()`.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// Execute parses `rawArgs` into `root` and invokes `Run` of the deepest selected command.
+// If no `rawArgs` were provided, it defaults to `os.Args[1:]`.
+// Invalid command line input is reported as UsageError holding the help of the affected command.
+func Execute[T any](ctx context.Context, root *T, rawArgs ...string) error {
+	return NewParser().Execute(ctx, root, rawArgs...)
+}
+
+// ExecuteAndExit works like `Execute()` but prints errors to stderr and terminates the program with `ExitCode()`.
+func ExecuteAndExit[T any](ctx context.Context, root *T, rawArgs ...string) {
+	NewParser().ExecuteAndExit(ctx, root, rawArgs...)
+}
+
+// ExitCode maps an error returned by `Execute()` to a process exit code.
+// Errors implementing ExitCoder decide on their own, usage errors yield ExitCodeUsage.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitCoder ExitCoder
+	if errors.As(err, &exitCoder) {
+		return exitCoder.ExitCode()
+	}
+
+	var usageErr UsageError
+	if errors.As(err, &usageErr) {
+		return ExitCodeUsage
+	}
+
+	return ExitCodeError
+}
+
+// Execute works like the package level `Execute()` but respects the parser's options.
+// `root` must be a pointer to a struct.
+func (p *Parser) Execute(ctx context.Context, root any, rawArgs ...string) error {
+	value := reflect.ValueOf(root)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return ErrNoStruct
+	}
+
+	result, err := p.parse(value.Elem(), rawArgs)
+	if err != nil {
+		return p.usageError(root, result.Path, err)
+	}
+
+	runner, ok := result.Command.(Runner)
+	if !ok {
+		return p.notRunnable(root, result)
+	}
+
+	return runner.Run(ctx, result.Trailing)
+}
+
+// ExecuteAndExit works like the package level `ExecuteAndExit()` but respects the parser's options.
+func (p *Parser) ExecuteAndExit(ctx context.Context, root any, rawArgs ...string) {
+	err := p.Execute(ctx, root, rawArgs...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+
+		var usageErr UsageError
+		if errors.As(err, &usageErr) && usageErr.Help != "" {
+			fmt.Fprint(os.Stderr, "\n"+usageErr.Help)
+		}
+	}
+
+	os.Exit(ExitCode(err))
+}
+
+// notRunnable reports a selected command without `Run`. If it has subcommands, one of them is required and a given
+// value is reported as unknown command.
+func (p *Parser) notRunnable(root any, result *ParseResult) error {
+	t := reflect.TypeOf(result.Command).Elem()
+	parsedTags, err := parseStructTags(t, p.conversion())
+	if err != nil {
+		return err
+	}
+
+	schema := newSchema(t, parsedTags, p.conversion())
+	if !schema.HasSubcommands() {
+		return NewNotRunnableError(t.String())
+	}
+
+	if len(result.Trailing) > 0 {
+		name := result.Trailing[0]
+		return p.usageError(root, result.Path, NewUnknownCommandError(name, suggest(name, schema.SubcommandNames())...))
+	}
+
+	return p.usageError(root, result.Path, NewCommandRequiredError(strings.Join(schema.SubcommandNames(), "|")))
+}
+
+// usageError wraps `err` into a UsageError with the help of the command at `path`.
+func (p *Parser) usageError(root any, path []string, err error) error {
+	help, helpErr := p.HelpCommand(root, DefaultHelpFormatter, path...)
+	if helpErr != nil {
+		help = ""
+	}
+	return NewUsageError(err, path, help)
+}
@@ -0,0 +1,130 @@
+package clapper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type runRecorder struct {
+	command  string
+	trailing []string
+}
+
+type runnableRemoteAdd struct {
+	Name     string `clapper:"long"`
+	recorder *runRecorder
+}
+
+func (c *runnableRemoteAdd) Run(_ context.Context, trailing []string) error {
+	c.recorder.command = "add:" + c.Name
+	c.recorder.trailing = trailing
+	return nil
+}
+
+type runnableRemote struct {
+	Add *runnableRemoteAdd `clapper:"subcommand"`
+}
+
+type failingStatus struct{}
+
+var errStatusFailed = errors.New("status failed")
+
+func (c *failingStatus) Run(context.Context, []string) error {
+	return errStatusFailed
+}
+
+type exitCodeError struct{}
+
+func (exitCodeError) Error() string { return "custom" }
+func (exitCodeError) ExitCode() int { return 42 }
+
+type runnableRoot struct {
+	Debug  bool            `clapper:"short"`
+	Remote *runnableRemote `clapper:"subcommand"`
+	Status *failingStatus  `clapper:"subcommand"`
+	Plain  *statusCmd      `clapper:"subcommand"`
+}
+
+func TestExecuteRunsDeepestCommand(t *testing.T) {
+	recorder := &runRecorder{}
+	root := runnableRoot{
+		Remote: &runnableRemote{Add: &runnableRemoteAdd{recorder: recorder}},
+	}
+
+	err := Execute(context.Background(), &root, "remote", "add", "origin", "--name", "up", "https://example.com")
+	require.NoError(t, err)
+
+	assert.Equal(t, "add:up", recorder.command)
+	assert.Equal(t, []string{"origin", "https://example.com"}, recorder.trailing)
+}
+
+func TestExecuteSubcommandPositionals(t *testing.T) {
+	recorder := &runRecorder{}
+	root := runnableRoot{
+		Remote: &runnableRemote{Add: &runnableRemoteAdd{recorder: recorder}},
+	}
+
+	err := Execute(context.Background(), &root, "remote", "add", "origin", "https://example.com", "--name=up")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"origin", "https://example.com"}, recorder.trailing)
+
+	err = Execute(context.Background(), &root, "remote", "add", "--name=up", "origin", "https://example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"origin", "https://example.com"}, recorder.trailing)
+}
+
+func TestExecuteReturnsRunError(t *testing.T) {
+	var root runnableRoot
+	err := Execute(context.Background(), &root, "status")
+	assert.ErrorIs(t, err, errStatusFailed)
+	assert.Equal(t, ExitCodeError, ExitCode(err))
+}
+
+func TestExecuteRequiresSubcommand(t *testing.T) {
+	var root runnableRoot
+	err := Execute(context.Background(), &root, "-D")
+	assert.ErrorIs(t, err, NewCommandRequiredError("plain|remote|status"))
+
+	var usageErr UsageError
+	require.ErrorAs(t, err, &usageErr)
+	assert.Empty(t, usageErr.Path)
+	assert.Contains(t, usageErr.Help, "Commands:")
+	assert.Equal(t, ExitCodeUsage, ExitCode(err))
+}
+
+func TestExecuteParseErrorIsUsageError(t *testing.T) {
+	var root runnableRoot
+	err := Execute(context.Background(), &root, "remote", "add")
+	assert.ErrorIs(t, err, NewMandatoryParameterError("name"))
+
+	var usageErr UsageError
+	require.ErrorAs(t, err, &usageErr)
+	assert.Equal(t, []string{"remote", "add"}, usageErr.Path)
+	assert.Contains(t, usageErr.Help, "--name")
+}
+
+func TestExecuteNotRunnable(t *testing.T) {
+	var root runnableRoot
+	err := Execute(context.Background(), &root, "plain")
+	assert.ErrorIs(t, err, NewNotRunnableError("clapper.statusCmd"))
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 0, ExitCode(nil))
+	assert.Equal(t, ExitCodeError, ExitCode(errors.New("some")))
+	assert.Equal(t, ExitCodeUsage, ExitCode(NewUsageError(ErrEmptyArgument, nil, "")))
+	assert.Equal(t, 42, ExitCode(exitCodeError{}))
+}
+
+func TestExecuteUnknownCommand(t *testing.T) {
+	for _, args := range [][]string{{"-D", "remtoe"}, {"remtoe"}, {"remtoe", "-D"}} {
+		var root runnableRoot
+		err := Execute(context.Background(), &root, args...)
+		assert.EqualError(t, err, "unknown command 'remtoe', did you mean 'remote'?")
+		assert.Equal(t, ExitCodeUsage, ExitCode(err))
+	}
+}
@@ -0,0 +1,352 @@
+package clapper
+
+import (
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// FlagSpec describes a single command line flag of a struct.
+type FlagSpec struct {
+	// Index of the struct field the flag belongs to.
+	Index int
+	// Type of the struct field.
+	Type reflect.Type
+	// Persistent flags are inherited by all subcommands.
+	Persistent bool
+	// Count flags count their occurrences instead of taking a value.
+	Count bool
+	// Nargs bounds the number of values each occurrence of a slice flag takes, if set.
+	Nargs *Nargs
+	// conv determines the types converted as a whole.
+	conv conversion
+}
+
+// TakesValue returns true if the flag expects a value following it on the command line.
+func (f FlagSpec) TakesValue() bool {
+	return !isBoolType(f.Type) && !f.Count
+}
+
+// TakesMultiple returns true if the flag takes all values following it (iE for slices and maps).
+func (f FlagSpec) TakesMultiple() bool {
+	return f.conv.isMultiValueType(f.Type)
+}
+
+// RequiredValues returns the number of values which have to follow each occurrence of the flag.
+func (f FlagSpec) RequiredValues() int {
+	switch {
+	case !f.TakesValue():
+		return 0
+	case f.Nargs != nil:
+		return f.Nargs.Min
+	default:
+		return 1
+	}
+}
+
+// AcceptsValue returns true if the flag takes another value after `given` values.
+func (f FlagSpec) AcceptsValue(given int) bool {
+	switch {
+	case !f.TakesValue():
+		return false
+	case f.Nargs != nil:
+		return given < f.Nargs.Max
+	case f.TakesMultiple():
+		return true
+	default:
+		return given == 0
+	}
+}
+
+// IsNumeric returns true if the flag's values are numbers.
+func (f FlagSpec) IsNumeric() bool {
+	t := f.Type
+	for (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice) && !f.conv.isValueType(t) {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// Schema knows all flags and subcommands of a single struct.
+// It allows interpreting command line arguments before they get assigned to any struct field.
+type Schema struct {
+	flags       map[ArgType]map[string]FlagSpec
+	subcommands map[string]int
+}
+
+// NewSchema creates a Schema from the struct type `t` and its parsed tags.
+func NewSchema(t reflect.Type, tags ParsedTags) *Schema {
+	return newSchema(t, tags, conversion{})
+}
+
+// newSchema works like NewSchema but determines the types converted as a whole by `conv`.
+func newSchema(t reflect.Type, tags ParsedTags, conv conversion) *Schema {
+	schema := &Schema{
+		flags: map[ArgType]map[string]FlagSpec{
+			ArgTypeShort: make(map[string]FlagSpec),
+			ArgTypeLong:  make(map[string]FlagSpec),
+		},
+		subcommands: make(map[string]int),
+	}
+
+	for index, tagMap := range tags {
+		if tagMap.IsSubcommand() {
+			schema.subcommands[tagMap.SubcommandName()] = index
+			continue
+		}
+		spec := FlagSpec{
+			Index:      index,
+			Type:       t.Field(index).Type,
+			Persistent: tagMap.HasTagType(TagPersistent),
+			Count:      tagMap.HasTagType(TagCount),
+			conv:       conv,
+		}
+		if nargs, ok := tagMap.Nargs(); ok {
+			spec.Nargs = &nargs
+		}
+		for _, tagType := range []TagType{TagShort, TagLong} {
+			if tag, ok := tagMap[tagType]; ok {
+				schema.flags[mustTagTypeToArgType(tagType)][tag.ArgumentName()] = spec
+			}
+		}
+		if name, ok := tagMap.NegationName(); ok {
+			schema.flags[ArgTypeLong][name] = spec
+		}
+	}
+
+	return schema
+}
+
+// Inherit adds all persistent flags of the `parent` command's schema which are not shadowed by own flags.
+// If any name of a parent's field is shadowed, none of its names is inherited.
+// Index and Type of inherited flags refer to the struct declaring them.
+func (s *Schema) Inherit(parent *Schema) *Schema {
+	if parent == nil {
+		return s
+	}
+	shadowed := make(map[int]bool)
+	for argType, flags := range parent.flags {
+		for name, spec := range flags {
+			if _, own := s.flags[argType][name]; own {
+				shadowed[spec.Index] = true
+			}
+		}
+	}
+	for argType, flags := range parent.flags {
+		for name, spec := range flags {
+			if spec.Persistent && !shadowed[spec.Index] {
+				s.flags[argType][name] = spec
+			}
+		}
+	}
+	return s
+}
+
+// Flag returns the FlagSpec for the flag `name` of the given type.
+func (s *Schema) Flag(name string, argType ArgType) (FlagSpec, bool) {
+	spec, ok := s.flags[argType][name]
+	return spec, ok
+}
+
+// Subcommand returns the struct field index of the subcommand `name`.
+func (s *Schema) Subcommand(name string) (index int, ok bool) {
+	index, ok = s.subcommands[name]
+	return index, ok
+}
+
+// HasSubcommands returns true if the struct has at least one field tagged as `subcommand`.
+func (s *Schema) HasSubcommands() bool {
+	return len(s.subcommands) > 0
+}
+
+// LongNames returns the names of all long flags, including inherited ones.
+func (s *Schema) LongNames() []string {
+	names := make([]string, 0, len(s.flags[ArgTypeLong]))
+	for name := range s.flags[ArgTypeLong] {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// SubcommandNames returns the names of all subcommands.
+func (s *Schema) SubcommandNames() []string {
+	names := make([]string, 0, len(s.subcommands))
+	for name := range s.subcommands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// flagFor returns the FlagSpec of an unsanitized flag argument.
+// Combined short flags (iE `-abc`) are represented by their last flag, an assigned value (iE `--foo=bar`) is ignored.
+func (s *Schema) flagFor(arg string) (FlagSpec, bool) {
+	arg, _, _ = strings.Cut(arg, "=")
+	argType := NewArgType(arg)
+	name := argType.Value(arg)
+	if argType == ArgTypeShort && len(name) > 1 {
+		flags, _ := splitShorts(s, arg)
+		name = ArgTypeShort.Value(flags[len(flags)-1])
+	}
+	return s.Flag(name, argType)
+}
+
+// splitShorts splits combined short flags into single flags (iE `-abc` -> `-a -b -c`).
+// With a schema given, the first flag taking a value ends the combination and the rest of the argument is its value
+// (iE `-vp8080` -> `-v -p` and `8080`). Without a schema, every letter is a flag.
+func splitShorts(schema *Schema, arg string) (flags []string, value string) {
+	letters := ArgTypeShort.Value(arg)
+	flags = make([]string, 0, len(letters))
+	for index, letter := range letters {
+		name := string(letter)
+		flags = append(flags, ArgTypeShort.Prefix()+name)
+		if schema == nil {
+			continue
+		}
+		if spec, ok := schema.Flag(name, ArgTypeShort); ok && spec.TakesValue() {
+			return flags, letters[index+len(name):]
+		}
+	}
+	return flags, ""
+}
+
+// hasAttachedValue returns true if the flag argument carries its first value (iE `-p8080` or `--color=false`).
+func hasAttachedValue(schema *Schema, arg string) bool {
+	if strings.Contains(arg, "=") {
+		return true
+	}
+	if NewArgType(arg) != ArgTypeShort {
+		return false
+	}
+	_, value := splitShorts(schema, arg)
+	return value != ""
+}
+
+// SplitAtSubcommand splits unsanitized arguments at the first value naming a subcommand.
+// Values required by the preceding flag (iE the first value following a flag that takes one) or following the
+// terminator are never taken as subcommand.
+// Returns the arguments before the subcommand, the subcommand's struct field index and all arguments after it.
+func (s *Schema) SplitAtSubcommand(args []string) (levelArgs []string, index int, rest []string, ok bool) {
+	expectValues := 0
+	for i, arg := range args {
+		if arg == Terminator {
+			break
+		}
+
+		if NewArgType(arg) != ArgTypeValue {
+			expectValues = 0
+			if spec, known := s.flagFor(arg); known {
+				expectValues = spec.RequiredValues()
+			}
+			if hasAttachedValue(s, arg) {
+				expectValues = max(expectValues-1, 0)
+			}
+			continue
+		}
+
+		if expectValues > 0 {
+			expectValues--
+			continue
+		}
+
+		if index, ok = s.Subcommand(arg); ok {
+			return args[:i], index, args[i+1:], true
+		}
+	}
+
+	return args, 0, nil, false
+}
+
+// negativeNumber matches arguments like `-5`, `-3.5`, `-.5` or `-1e3`.
+var negativeNumber = regexp.MustCompile(`^-(\d+(\.\d*)?|\.\d+)([eE][-+]?\d+)?$`)
+
+// negativeNumberLike matches arguments starting like a negative number, which includes numbers with unit prefixes or
+// in Go literal syntax (iE `-10k` or `-0x1F`).
+var negativeNumberLike = regexp.MustCompile(`^-\.?\d`)
+
+// ArgClassifier determines the ArgType of arguments in order, taking the schema and preceding flags into account.
+// Without a schema, it behaves like `NewArgType()` except for arguments after the terminator, which are values.
+type ArgClassifier struct {
+	schema     *Schema
+	flag       *FlagSpec
+	values     int
+	terminated bool
+}
+
+// NewArgClassifier returns an ArgClassifier for the given schema, which may be nil.
+func NewArgClassifier(schema *Schema) *ArgClassifier {
+	return &ArgClassifier{schema: schema}
+}
+
+// Classify returns the ArgType of the next argument.
+func (c *ArgClassifier) Classify(arg string) ArgType {
+	argType := NewArgType(arg)
+	switch {
+	case c.terminated:
+		argType = ArgTypeValue
+	case argType == ArgTypeShort && c.isNegativeNumber(arg):
+		argType = ArgTypeValue
+	}
+
+	switch argType {
+	case ArgTypeValue:
+		c.values++
+	case ArgTypeTerminator:
+		c.terminated = true
+		c.flag = nil
+	default:
+		c.flag = nil
+		if spec, ok := c.flagFor(arg); ok {
+			c.flag = &spec
+		}
+		c.values = 0
+		// An attached or assigned value (iE `-p8080`, `--color=false`) is the flag's first value.
+		if hasAttachedValue(c.schema, arg) {
+			c.values = 1
+		}
+	}
+
+	return argType
+}
+
+// ClassifyToken returns the ArgType of the next token. Assigned tokens are always values.
+func (c *ArgClassifier) ClassifyToken(token Token) ArgType {
+	if token.Assigned {
+		c.values++
+		return ArgTypeValue
+	}
+	return c.Classify(token.Arg)
+}
+
+// isNegativeNumber returns true if `arg` is a negative number which is a value rather than a short flag.
+// That is the case if the preceding flag expects a number, which also takes anything starting like a number (iE `-10k`),
+// or if there is no short flag named like the first digit.
+func (c *ArgClassifier) isNegativeNumber(arg string) bool {
+	if c.schema == nil || !negativeNumberLike.MatchString(arg) {
+		return false
+	}
+	if c.flag != nil && c.flag.IsNumeric() && c.flag.AcceptsValue(c.values) {
+		return true
+	}
+	if !negativeNumber.MatchString(arg) {
+		return false
+	}
+	_, isFlag := c.schema.Flag(arg[1:2], ArgTypeShort)
+	return !isFlag
+}
+
+func (c *ArgClassifier) flagFor(arg string) (FlagSpec, bool) {
+	if c.schema == nil {
+		return FlagSpec{}, false
+	}
+	return c.schema.flagFor(arg)
+}
@@ -0,0 +1,160 @@
+package clapper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaSplitAtSubcommand(t *testing.T) {
+	type Sub struct{}
+	type Foo struct {
+		Name    string `clapper:"short,long"`
+		Flag    bool   `clapper:"short=f"`
+		Numbers []int  `clapper:"long"`
+		Run     *Sub   `clapper:"subcommand"`
+		Stop    *Sub   `clapper:"subcommand=halt"`
+	}
+
+	tests := []struct {
+		name      string
+		input     []string
+		wantLevel []string
+		wantIndex int
+		wantRest  []string
+		wantOk    bool
+	}{
+		{
+			name:      "no subcommand",
+			input:     []string{"--name", "foo", "bar"},
+			wantLevel: []string{"--name", "foo", "bar"},
+			wantOk:    false,
+		},
+		{
+			name:      "subcommand first",
+			input:     []string{"run", "--name", "foo"},
+			wantLevel: []string{},
+			wantIndex: 3,
+			wantRest:  []string{"--name", "foo"},
+			wantOk:    true,
+		},
+		{
+			name:      "subcommand after flags",
+			input:     []string{"-f", "halt", "now"},
+			wantLevel: []string{"-f"},
+			wantIndex: 4,
+			wantRest:  []string{"now"},
+			wantOk:    true,
+		},
+		{
+			name:      "subcommand name as flag value",
+			input:     []string{"--name", "run", "halt"},
+			wantLevel: []string{"--name", "run"},
+			wantIndex: 4,
+			wantRest:  []string{},
+			wantOk:    true,
+		},
+		{
+			name:      "subcommand name after assigned flag value",
+			input:     []string{"--name=foo", "run"},
+			wantLevel: []string{"--name=foo"},
+			wantIndex: 3,
+			wantRest:  []string{},
+			wantOk:    true,
+		},
+		{
+			name:      "subcommand name as combined short value",
+			input:     []string{"-fN", "run"},
+			wantLevel: []string{"-fN", "run"},
+			wantOk:    false,
+		},
+		{
+			name:      "subcommand after slice values",
+			input:     []string{"--numbers", "1", "2", "run"},
+			wantLevel: []string{"--numbers", "1", "2"},
+			wantIndex: 3,
+			wantRest:  []string{},
+			wantOk:    true,
+		},
+	}
+
+	typ := reflect.TypeOf(Foo{})
+	tags, err := parseStructTags(typ, conversion{})
+	require.NoError(t, err)
+	schema := NewSchema(typ, tags)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, index, rest, ok := schema.SplitAtSubcommand(tt.input)
+			require.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantLevel, level)
+			if tt.wantOk {
+				assert.Equal(t, tt.wantIndex, index)
+				assert.Equal(t, tt.wantRest, rest)
+			}
+		})
+	}
+}
+
+func TestArgClassifier(t *testing.T) {
+	type Foo struct {
+		Offset  int     `clapper:"long"`
+		Numbers []int   `clapper:"long"`
+		Name    string  `clapper:"short"`
+		Five    bool    `clapper:"short=5"`
+		Ratio   float64 `clapper:"short=r"`
+	}
+
+	typ := reflect.TypeOf(Foo{})
+	tags, err := parseStructTags(typ, conversion{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		schema   *Schema
+		input    []string
+		expected []ArgType
+	}{
+		{
+			name:     "without schema",
+			input:    []string{"--offset", "-5", "--", "-x"},
+			expected: []ArgType{ArgTypeLong, ArgTypeShort, ArgTypeTerminator, ArgTypeValue},
+		},
+		{
+			name:     "after numeric flag",
+			schema:   NewSchema(typ, tags),
+			input:    []string{"--offset", "-5", "-5"},
+			expected: []ArgType{ArgTypeLong, ArgTypeValue, ArgTypeShort},
+		},
+		{
+			name:     "after numeric slice flag",
+			schema:   NewSchema(typ, tags),
+			input:    []string{"--numbers", "-5", "1", "-5"},
+			expected: []ArgType{ArgTypeLong, ArgTypeValue, ArgTypeValue, ArgTypeValue},
+		},
+		{
+			name:     "no short flag for digit",
+			schema:   NewSchema(typ, tags),
+			input:    []string{"-N", "-3.5", "-1e3", "-4x"},
+			expected: []ArgType{ArgTypeShort, ArgTypeValue, ArgTypeValue, ArgTypeShort},
+		},
+		{
+			name:     "after non numeric flag with digit short",
+			schema:   NewSchema(typ, tags),
+			input:    []string{"-N", "-5", "-r", "-5.5"},
+			expected: []ArgType{ArgTypeShort, ArgTypeShort, ArgTypeShort, ArgTypeValue},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classifier := NewArgClassifier(tt.schema)
+			got := make([]ArgType, 0, len(tt.input))
+			for _, arg := range tt.input {
+				got = append(got, classifier.Classify(arg))
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
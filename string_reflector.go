@@ -1,9 +1,15 @@
 package clapper
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"math"
+	"os"
 	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 
 	internalerrors "github.com/mittwald/clapper/internal/errors"
 )
@@ -20,6 +26,82 @@ func isBool(field reflect.StructField) bool {
 	return field.Type.Kind() == reflect.Bool
 }
 
+// isBoolType returns true for `bool` and `*bool`.
+func isBoolType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
+
+// isIntegerType returns true for all signed and unsigned integer types and pointers to them.
+func isIntegerType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// isTimeType returns true for `time.Time` and pointers, slices and maps of it.
+func (c conversion) isTimeType(t reflect.Type) bool {
+	return c.valueElemType(t) == timeType
+}
+
+// isUnsignedType returns true for all unsigned integer types.
+func isUnsignedType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseBool accepts exactly `true`, `false`, `yes`, `no`, `1` and `0`.
+func parseBool(input string) (bool, error) {
+	switch input {
+	case "true", "yes", "1":
+		return true, nil
+	case "false", "no", "0":
+		return false, nil
+	default:
+		return false, strconv.ErrSyntax
+	}
+}
+
+// parseBool converts the value of the bool or *bool type `t`. Bool types converted as a whole (iE by a Converter
+// taking `on` and `off`) are converted by their conversion, all others by the package level `parseBool()`.
+func (c conversion) parseBool(t reflect.Type, input string) (bool, error) {
+	elemType := t
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if c.isValueType(elemType) {
+		value, _, err := c.valueFromString(elemType, []string{input})
+		if err != nil {
+			return false, err
+		}
+		return value.Bool(), nil
+	}
+	b, err := parseBool(input)
+	if err != nil {
+		return false, NewUnexpectedInputFormatError(input, t)
+	}
+	return b, nil
+}
+
+// isMultiValueType returns true for types taking all values of a flag, which are slices and maps.
+// Slice types converted as a whole (iE net.IP) are single values.
+func (c conversion) isMultiValueType(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Map) && !c.isValueType(t)
+}
+
 func isOptionalField(field reflect.StructField) bool {
 	return isPointer(field) || isBool(field)
 }
@@ -30,35 +112,296 @@ func trySetForType(
 	fieldValue reflect.Value,
 	tags map[TagType]Tag,
 	args *ArgParserExt,
+	opts fieldOptions,
 ) error {
-	key, values := valuesFor(tagType, tags, args)
-	if values == nil {
+	key, found := valuesFor(tagType, tags, args)
+	if found == nil {
 		return internalerrors.ErrInternalNoArgumentsForTag
 	}
 
-	took, err := StringReflect(field, fieldValue, values)
-	if err != nil {
-		return err
+	values := make([]string, 0, len(found))
+	for _, value := range found {
+		values = append(values, value.Value)
 	}
+	took, err := stringReflectTagged(field, fieldValue, tags, values, opts)
 
+	// The flag is known even if its values are malformed, so it gets consumed anyways.
 	argType := mustTagTypeToArgType(tagType)
 	args.Consume(key, argType, took)
 
+	return argumentErrorAt(err, found)
+}
+
+// trySetEnv sets the field from the environment variable named by the `env` tag.
+// Boolean fields take the variable's value literally, so `FOO=false` turns a flag off.
+func trySetEnv(field reflect.StructField, fieldValue reflect.Value, tags TagMap, opts fieldOptions) error {
+	tag, ok := tags[TagEnv]
+	if !ok {
+		return internalerrors.ErrInternalNoArgumentsForTag
+	}
+	value, ok := os.LookupEnv(tag.ArgumentName())
+	if !ok {
+		return internalerrors.ErrInternalNoArgumentsForTag
+	}
+
+	if isBoolType(field.Type) {
+		return setBoolFromString(field, fieldValue, value, opts.conversion(tags))
+	}
+
+	_, err := stringReflectTagged(field, fieldValue, tags, []string{value}, opts)
+	return argumentErrorAt(err, nil)
+}
+
+// setBoolFromString sets a bool or *bool field to the boolean `value`.
+func setBoolFromString(field reflect.StructField, fieldValue reflect.Value, value string, conv conversion) error {
+	b, err := conv.parseBool(field.Type, value)
+	if err != nil {
+		return err
+	}
+	setBool(field, fieldValue, b)
+	return nil
+}
+
+// setBool sets a bool or *bool field. A *bool points to a fresh value, so `nil` means the flag was not given.
+func setBool(field reflect.StructField, fieldValue reflect.Value, b bool) {
+	if isPointer(field) {
+		elem := reflect.New(field.Type.Elem())
+		elem.Elem().SetBool(b)
+		fieldValue.Set(elem)
+	} else {
+		fieldValue.SetBool(b)
+	}
+}
+
+// flagRefsByPrecedence returns the flags of a field grouped by precedence, the long spellings before the short one.
+func flagRefsByPrecedence(tags TagMap) [][]flagRef {
+	result := make([][]flagRef, 0, 2)
+	if tag, ok := tags[TagLong]; ok {
+		long := []flagRef{{key: tag.ArgumentName(), argType: ArgTypeLong}}
+		if name, ok := tags.NegationName(); ok {
+			long = append(long, flagRef{key: name, argType: ArgTypeLong})
+		}
+		result = append(result, long)
+	}
+	if tag, ok := tags[TagShort]; ok {
+		result = append(result, []flagRef{{key: tag.ArgumentName(), argType: ArgTypeShort}})
+	}
+	return result
+}
+
+// pickOccurrence returns the occurrence of a non-slice field's flags which sets the field according to `policy`.
+// With RepeatFirstWins, the long flag takes precedence over the short one.
+// All occurrences are consumed with their values as determined by `took`, even if they do not set the field.
+func pickOccurrence(tags TagMap, args *ArgParserExt, policy RepeatPolicy, took func(occurrence) int) (*occurrence, error) {
+	groups := flagRefsByPrecedence(tags)
+	all := args.occurrences(slices.Concat(groups...)...)
+	if len(all) == 0 {
+		return nil, internalerrors.ErrInternalNoArgumentsForTag
+	}
+	for _, found := range all {
+		found.consume(took(found))
+	}
+
+	switch policy {
+	case RepeatLastWins:
+		return &all[len(all)-1], nil
+	case RepeatError:
+		if len(all) > 1 {
+			return nil, NewArgumentError(NewRepeatedFlagError(all[1].flag.String()), *all[1].flag)
+		}
+		return &all[0], nil
+	default:
+		for _, refs := range groups {
+			if found := args.occurrences(refs...); len(found) > 0 {
+				return &found[0], nil
+			}
+		}
+		return &all[0], nil
+	}
+}
+
+// assignedValue returns the value bound to the flag of the occurrence (iE `--foo=bar`) or nil.
+func assignedValue(found occurrence) *ArgValue {
+	if len(found.values) > 0 && found.values[0].Assigned {
+		return found.values[0]
+	}
 	return nil
 }
 
-func trySetDefault(field reflect.StructField, fieldValue reflect.Value, tags TagMap) error {
+// trySetBool sets a bool field if one of its flags is given. A value is only accepted if it is assigned with `=`
+// (iE `--color=false`), so a following value is never taken. The negation of a `negatable` bool inverts the value.
+func trySetBool(field reflect.StructField, fieldValue reflect.Value, tags TagMap, args *ArgParserExt, opts fieldOptions) error {
+	found, err := pickOccurrence(tags, args, opts.repeat, func(found occurrence) int {
+		if assignedValue(found) != nil {
+			return 1
+		}
+		return 0
+	})
+	if err != nil {
+		return err
+	}
+
+	b := true
+	if assigned := assignedValue(*found); assigned != nil {
+		if b, err = opts.conversion(tags).parseBool(field.Type, assigned.Value); err != nil {
+			return NewArgumentError(err, *assigned)
+		}
+	}
+
+	name, _ := tags.NegationName()
+	negated := found.flag.Type == ArgTypeLong && found.flag.Value == name
+	setBool(field, fieldValue, b != negated)
+	return nil
+}
+
+// trySetScalar sets a non-slice field from the value following one of its flags.
+func trySetScalar(field reflect.StructField, fieldValue reflect.Value, tags TagMap, args *ArgParserExt, opts fieldOptions) error {
+	found, err := pickOccurrence(tags, args, opts.repeat, func(occurrence) int { return 1 })
+	if err != nil {
+		return err
+	}
+
+	values := make([]string, 0, len(found.values))
+	for _, value := range found.values {
+		values = append(values, value.Value)
+	}
+	if _, err = opts.conversion(tags).stringReflect(field, fieldValue, values); err != nil {
+		// The error points to the offending value, or to the flag if no value follows.
+		at := found.flag
+		if len(found.values) > 0 {
+			at = found.values[0]
+		}
+		return NewArgumentError(err, *at)
+	}
+	return nil
+}
+
+// trySetFlagValue sets a field of a type implementing flag.Value like the flag package does: `Set` is called on the
+// same value for every occurrence of its flags in the order given, so a `Set` appending its input collects all of them.
+// Only RepeatError is respected of the RepeatPolicies.
+func trySetFlagValue(field reflect.StructField, fieldValue reflect.Value, tags TagMap, args *ArgParserExt, opts fieldOptions) error {
+	if _, err := pickOccurrence(tags, args, opts.repeat, func(occurrence) int { return 1 }); err != nil {
+		return err
+	}
+
+	target := reflect.New(valueType(field))
+	decoder := target.Interface().(flag.Value)
+	for _, found := range args.occurrences(slices.Concat(flagRefsByPrecedence(tags)...)...) {
+		if len(found.values) == 0 {
+			return NewArgumentError(ErrEmptyArgument, *found.flag)
+		}
+		value := found.values[0]
+		if err := decoder.Set(value.Value); err != nil {
+			return NewArgumentError(newConversionError(value.Value, valueType(field), err), *value)
+		}
+	}
+
+	if isPointer(field) {
+		fieldValue.Set(target)
+	} else {
+		fieldValue.Set(target.Elem())
+	}
+	return nil
+}
+
+// valueType returns the type of the field's value, which is the element type of pointers.
+func valueType(field reflect.StructField) reflect.Type {
+	if isPointer(field) {
+		return field.Type.Elem()
+	}
+	return field.Type
+}
+
+// trySetBoundedSlice sets a slice field with `nargs` from all occurrences of its flags in the order given.
+// Each occurrence takes at least the minimum and at most the maximum number of values, the rest is left untouched.
+func trySetBoundedSlice(
+	field reflect.StructField,
+	fieldValue reflect.Value,
+	tags TagMap,
+	args *ArgParserExt,
+	opts fieldOptions,
+) error {
+	nargs, _ := tags.Nargs()
+	all := args.occurrences(slices.Concat(flagRefsByPrecedence(tags)...)...)
+	if len(all) == 0 {
+		return internalerrors.ErrInternalNoArgumentsForTag
+	}
+
+	given := make([]*ArgValue, 0)
+	values := make([]string, 0)
+	for _, found := range all {
+		// The flag is known even if too few values follow, so it gets consumed anyways.
+		took := min(nargs.Max, len(found.values))
+		found.consume(took)
+		if took < nargs.Min {
+			return NewArgumentError(NewTooFewValuesError(found.flag.String(), nargs.Min, took), *found.flag)
+		}
+		for _, value := range found.values[:took] {
+			given = append(given, value)
+			values = append(values, value.Value)
+		}
+	}
+
+	_, err := stringReflectTagged(field, fieldValue, tags, values, opts)
+	return argumentErrorAt(err, given)
+}
+
+// trySetCount sets a `count` field to the number of occurrences of its short and long flags.
+// Count flags take no value, so an assigned one (iE `--verbose=3`) is an error.
+func trySetCount(field reflect.StructField, fieldValue reflect.Value, tags TagMap, args *ArgParserExt) error {
+	for _, found := range args.occurrences(slices.Concat(flagRefsByPrecedence(tags)...)...) {
+		if assigned := assignedValue(found); assigned != nil {
+			return NewArgumentError(NewUnexpectedInputFormatError(assigned.Value, field.Type), *assigned)
+		}
+	}
+
+	count := 0
+	for _, tagType := range []TagType{TagShort, TagLong} {
+		tag, ok := tags[tagType]
+		if !ok {
+			continue
+		}
+		key := tag.ArgumentName()
+		argType := mustTagTypeToArgType(tagType)
+		count += args.Count(key, argType)
+		args.Consume(key, argType, 0)
+	}
+
+	if count == 0 {
+		return internalerrors.ErrInternalNoArgumentsForTag
+	}
+
+	if isPointer(field) {
+		fieldValue.Set(reflect.New(field.Type.Elem()))
+		fieldValue = fieldValue.Elem()
+	}
+	if fieldValue.CanInt() && !fieldValue.OverflowInt(int64(count)) {
+		fieldValue.SetInt(int64(count))
+		return nil
+	}
+	if fieldValue.CanUint() && !fieldValue.OverflowUint(uint64(count)) {
+		fieldValue.SetUint(uint64(count))
+		return nil
+	}
+	return newIntegerRangeError(strconv.Itoa(count), fieldValue.Type())
+}
+
+func trySetDefault(field reflect.StructField, fieldValue reflect.Value, tags TagMap, opts fieldOptions) error {
 	tag, ok := tags[TagDefault]
 	if !ok {
-		if isOptionalField(field) {
+		// A count flag which is not given has been given zero times.
+		if isOptionalField(field) || tags.HasTagType(TagCount) {
 			return nil
 		}
 		return NewMandatoryParameterError(tags.InputArgument())
 	}
+	if isBoolType(field.Type) {
+		return setBoolFromString(field, fieldValue, tag.Value, opts.conversion(tags))
+	}
 	values := []string{tag.Value}
-	_, err := StringReflect(field, fieldValue, values)
+	_, err := stringReflectTagged(field, fieldValue, tags, values, opts)
 	if err != nil {
-		return err
+		return argumentErrorAt(err, nil)
 	}
 	return nil
 }
@@ -68,19 +411,46 @@ func trySetFieldConsumingArgs(
 	fieldValue reflect.Value,
 	tags TagMap,
 	args *ArgParserExt,
+	opts fieldOptions,
 ) error {
 	if !fieldValue.CanSet() {
 		return ErrFieldCanNotBeSet
 	}
 
-	shortErr := trySetForType(TagShort, field, fieldValue, tags, args)
-	longErr := trySetForType(TagLong, field, fieldValue, tags, args)
+	var err error
+	switch {
+	case tags.HasTagType(TagCount):
+		err = trySetCount(field, fieldValue, tags, args)
+	case isBoolType(field.Type):
+		err = trySetBool(field, fieldValue, tags, args, opts)
+	case tags.HasTagType(TagNargs):
+		err = trySetBoundedSlice(field, fieldValue, tags, args, opts)
+	case opts.conversion(tags).isFlagValueType(valueType(field)):
+		err = trySetFlagValue(field, fieldValue, tags, args, opts)
+	case opts.conversion(tags).isMultiValueType(field.Type):
+		// The long flag overrides the short one.
+		shortErr := trySetForType(TagShort, field, fieldValue, tags, args, opts)
+		err = trySetForType(TagLong, field, fieldValue, tags, args, opts)
+		if errors.Is(err, internalerrors.ErrInternalNoArgumentsForTag) {
+			err = shortErr
+		}
+	default:
+		err = trySetScalar(field, fieldValue, tags, args, opts)
+	}
 
-	if shortErr != nil && longErr != nil {
-		return trySetDefault(field, fieldValue, tags)
+	if !errors.Is(err, internalerrors.ErrInternalNoArgumentsForTag) {
+		return err
 	}
+	return trySetFallback(field, fieldValue, tags, opts)
+}
 
-	return nil
+// trySetFallback sets a field not given on the command line from the environment or its default.
+func trySetFallback(field reflect.StructField, fieldValue reflect.Value, tags TagMap, opts fieldOptions) error {
+	envErr := trySetEnv(field, fieldValue, tags, opts)
+	if !errors.Is(envErr, internalerrors.ErrInternalNoArgumentsForTag) {
+		return envErr
+	}
+	return trySetDefault(field, fieldValue, tags, opts)
 }
 
 func inputNeededForKind(kind reflect.Kind) bool {
@@ -109,22 +479,64 @@ func parseFloat(input string, bits int) (*reflect.Value, error) {
 	return nil, NewUnsupportedReflectTypeError(fmt.Sprintf("float%d", bits))
 }
 
+// newIntegerRangeError returns an UnexpectedInputFormatError stating the range of the integer type `t`.
+func newIntegerRangeError(input string, t reflect.Type) UnexpectedInputFormatError {
+	var lowest, highest string
+	if isUnsignedType(t) {
+		lowest, highest = "0", strconv.FormatUint(math.MaxUint64>>(64-t.Bits()), 10)
+	} else {
+		lowest = strconv.FormatInt(math.MinInt64>>(64-t.Bits()), 10)
+		highest = strconv.FormatInt(math.MaxInt64>>(64-t.Bits()), 10)
+	}
+	return NewUnexpectedInputFormatErrorExpecting(input, t, fmt.Sprintf("%s between %s and %s", t, lowest, highest))
+}
+
+// ValueFromString converts the first of the inputs to `fieldType` without any parser options.
 func ValueFromString(fieldType reflect.Type, inputs []string) (*reflect.Value, int, error) {
-	if inputNeededForKind(fieldType.Kind()) && len(inputs) == 0 {
+	return conversion{}.valueFromString(fieldType, inputs)
+}
+
+// valueFromString converts the first of the inputs to `fieldType` and returns the number of inputs taken.
+func (c conversion) valueFromString(fieldType reflect.Type, inputs []string) (*reflect.Value, int, error) {
+	// Types converted as a whole always need an input, even if they are bools.
+	if len(inputs) == 0 && (inputNeededForKind(fieldType.Kind()) || c.isValueType(fieldType)) {
 		return nil, 0, ErrEmptyArgument
 	}
 
+	if converter, ok := c.converterFor(fieldType); ok {
+		value, err := converter.valueFromString(inputs[0])
+		if err != nil {
+			return nil, 0, err
+		}
+		return value, 1, nil
+	}
+
+	if parse, ok := typeParsers[fieldType]; ok {
+		value, err := parse(c, inputs[0])
+		if err != nil {
+			return nil, 0, err
+		}
+		return value, 1, nil
+	}
+
+	if isUnmarshalerType(fieldType) {
+		value, err := unmarshal(fieldType, inputs[0])
+		if err != nil {
+			return nil, 0, err
+		}
+		return value, 1, nil
+	}
+
 	switch fieldType.Kind() {
 	case reflect.String:
 		return ptr(reflect.ValueOf(inputs[0])), 1, nil
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
 		reflect.Uint64, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		num, err := strconv.Atoi(inputs[0])
+		num, err := c.parseInteger(inputs[0], fieldType)
 		if err != nil {
-			err = NewUnexpectedInputFormatError(inputs[0], fieldType)
 			return nil, 0, err
 		}
-		return ptr(reflect.ValueOf(num)), 1, nil
+		return num, 1, nil
 	case reflect.Float32, reflect.Float64:
 		val, err := parseFloat(inputs[0], fieldType.Bits())
 		if err != nil {
@@ -135,31 +547,160 @@ func ValueFromString(fieldType reflect.Type, inputs []string) (*reflect.Value, i
 	case reflect.Bool:
 		b := true
 		return ptr(reflect.ValueOf(b)), 0, nil
+	case reflect.Pointer:
+		// Pointers are only converted here as elements of slices and maps (iE []*url.URL).
+		elem, took, err := c.valueFromString(fieldType.Elem(), inputs)
+		if err != nil {
+			return nil, 0, err
+		}
+		result := reflect.New(fieldType.Elem())
+		result.Elem().Set(*elem)
+		return &result, took, nil
 	default:
 		return nil, 0, NewUnsupportedReflectTypeError(fieldType.String())
 	}
 }
 
+// stringReflectTagged works like StringReflect but splits the values of slice and map fields at the `sep` of the tags
+// first. Duplicate keys of map fields are handled according to the parser's RepeatPolicy.
+// The number of values taken refers to the values before splitting.
+func stringReflectTagged(
+	field reflect.StructField,
+	fieldValue reflect.Value,
+	tags TagMap,
+	values []string,
+	opts fieldOptions,
+) (int, error) {
+	split := values
+	// origins hold the index of the value each split value originates from.
+	origins := make([]int, 0, len(values))
+	for index := range values {
+		origins = append(origins, index)
+	}
+	if sep, ok := tags.Separator(); ok {
+		split = make([]string, 0, len(values))
+		origins = origins[:0]
+		for index, value := range values {
+			parts := strings.Split(value, sep)
+			split = append(split, parts...)
+			for range parts {
+				origins = append(origins, index)
+			}
+		}
+	}
+
+	conv := opts.conversion(tags)
+	if field.Type.Kind() == reflect.Map {
+		return len(values), originOf(conv.setMap(field.Type, fieldValue, split, opts.repeat), origins)
+	}
+	if _, err := conv.stringReflect(field, fieldValue, split); err != nil {
+		return 0, originOf(err, origins)
+	}
+	return len(values), nil
+}
+
+// elementError is the error of a single value of a slice or map field, which knows the index of that value.
+type elementError struct {
+	error
+	index int
+}
+
+func (e elementError) Unwrap() error {
+	return e.error
+}
+
+// originOf maps the index of an elementError to the index of the value the failing split value originates from.
+func originOf(err error, origins []int) error {
+	var elemErr elementError
+	if errors.As(err, &elemErr) {
+		elemErr.index = origins[elemErr.index]
+		return elemErr
+	}
+	return err
+}
+
+// argumentErrorAt turns the error of a single value into an ArgumentError pointing to the value's argument.
+// Values not given on the command line (iE from the environment) keep their plain error.
+func argumentErrorAt(err error, values []*ArgValue) error {
+	var elemErr elementError
+	if !errors.As(err, &elemErr) {
+		return err
+	}
+	if elemErr.index < len(values) {
+		return NewArgumentError(elemErr.error, *values[elemErr.index])
+	}
+	return elemErr.error
+}
+
+// setMap sets a map field from `key=value` pairs, converting both parts to the map's key and element type.
+func (c conversion) setMap(mapType reflect.Type, fieldValue reflect.Value, values []string, policy RepeatPolicy) error {
+	result := reflect.MakeMapWithSize(mapType, len(values))
+	for index, value := range values {
+		key, elem, ok := strings.Cut(value, "=")
+		if !ok {
+			return elementError{NewUnexpectedInputFormatError(value, mapType), index}
+		}
+		refKey, _, err := c.valueFromString(mapType.Key(), []string{key})
+		if err != nil {
+			return elementError{err, index}
+		}
+		refElem, _, err := c.valueFromString(mapType.Elem(), []string{elem})
+		if err != nil {
+			return elementError{err, index}
+		}
+
+		if result.MapIndex(*refKey).IsValid() {
+			switch policy {
+			case RepeatLastWins:
+				// The later value replaces the earlier one below.
+			case RepeatError:
+				return elementError{NewDuplicateKeyError(key), index}
+			default:
+				continue
+			}
+		}
+		result.SetMapIndex(*refKey, *refElem)
+	}
+	fieldValue.Set(result)
+	return nil
+}
+
 func StringReflect(field reflect.StructField, fieldValue reflect.Value, values []string) (int, error) {
+	took, err := conversion{}.stringReflect(field, fieldValue, values)
+	return took, argumentErrorAt(err, nil)
+}
+
+// stringReflect sets the field from the values and returns the number of values taken.
+func (c conversion) stringReflect(field reflect.StructField, fieldValue reflect.Value, values []string) (int, error) {
 	took := 0
-	switch field.Type.Kind() {
+	kind := field.Type.Kind()
+	if c.isValueType(field.Type) {
+		// Types converted as a whole are set like single values, even if they are slices (iE net.IP).
+		kind = reflect.Invalid
+	}
+	switch kind {
 	case reflect.Slice:
 		slice := reflect.MakeSlice(field.Type, len(values), len(values))
 		took = len(values)
 		for i, value := range values {
 			elem := reflect.New(field.Type.Elem()).Elem()
-			refValue, _, err := ValueFromString(field.Type.Elem(), []string{value})
+			refValue, _, err := c.valueFromString(field.Type.Elem(), []string{value})
 			if err != nil {
-				return 0, err
+				return 0, elementError{err, i}
 			}
 			elem.Set(*refValue)
 			slice.Index(i).Set(elem)
 		}
 		fieldValue.Set(slice)
+	case reflect.Map:
+		if err := c.setMap(field.Type, fieldValue, values, RepeatFirstWins); err != nil {
+			return 0, err
+		}
+		took = len(values)
 	case reflect.Pointer:
 		elem := reflect.New(field.Type.Elem()).Elem()
 		ind := reflect.Indirect(elem)
-		v, tookCount, err := ValueFromString(ind.Type(), values)
+		v, tookCount, err := c.valueFromString(ind.Type(), values)
 		if err != nil {
 			return 0, err
 		}
@@ -167,7 +708,7 @@ func StringReflect(field reflect.StructField, fieldValue reflect.Value, values [
 		elem.Set(*v)
 		fieldValue.Set(elem.Addr())
 	default:
-		value, tookCount, err := ValueFromString(field.Type, values)
+		value, tookCount, err := c.valueFromString(field.Type, values)
 		if err != nil {
 			return 0, err
 		}
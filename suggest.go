@@ -0,0 +1,68 @@
+package clapper
+
+import (
+	"cmp"
+	"slices"
+)
+
+// maxSuggestionDistance is the maximum edit distance for a known name to be suggested for a mistyped one.
+const maxSuggestionDistance = 2
+
+// editDistance returns the optimal string alignment distance between `a` and `b`,
+// which is the Levenshtein distance with transpositions of adjacent characters counting as a single edit.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous2 := make([]int, len(rb)+1)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				current[j] = min(current[j], previous2[j-2]+1)
+			}
+		}
+		previous2, previous, current = previous, current, previous2
+	}
+
+	return previous[len(rb)]
+}
+
+// suggest returns all `candidates` close to but different from `input`, the closest first.
+func suggest(input string, candidates []string) []string {
+	type scored struct {
+		name     string
+		distance int
+	}
+
+	// Very short inputs would match nearly anything.
+	limit := min(maxSuggestionDistance, len(input)/2)
+	matches := make([]scored, 0)
+	for _, candidate := range candidates {
+		// An exact match is known, but not at the position it was given.
+		if distance := editDistance(input, candidate); distance > 0 && distance <= limit {
+			matches = append(matches, scored{name: candidate, distance: distance})
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b scored) int {
+		return cmp.Or(cmp.Compare(a.distance, b.distance), cmp.Compare(a.name, b.name))
+	})
+
+	result := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if !slices.Contains(result, match.name) {
+			result = append(result, match.name)
+		}
+	}
+	return result
+}
@@ -0,0 +1,39 @@
+package clapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "", b: "", expected: 0},
+		{a: "abc", b: "", expected: 3},
+		{a: "", b: "abc", expected: 3},
+		{a: "verbose", b: "verbose", expected: 0},
+		{a: "verbsoe", b: "verbose", expected: 1},
+		{a: "dyr-run", b: "dry-run", expected: 1},
+		{a: "versio", b: "version", expected: 1},
+		{a: "kitten", b: "sitting", expected: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.expected, editDistance(tt.a, tt.b))
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"verbose", "version", "force", "dry-run", "host", "post"}
+
+	assert.Equal(t, []string{"verbose"}, suggest("verbsoe", candidates))
+	assert.Equal(t, []string{"version"}, suggest("versio", candidates))
+	assert.Equal(t, []string{"host", "post"}, suggest("hast", candidates))
+	assert.Empty(t, suggest("verbose", candidates))
+	assert.Empty(t, suggest("x", candidates))
+	assert.Empty(t, suggest("something", candidates))
+}
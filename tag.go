@@ -1,20 +1,39 @@
 package clapper
 
 import (
+	"strconv"
 	"strings"
 	"unicode"
 )
 
 type TagType int
 
+// envOptOut as `env=-` excludes a field from automatically derived environment variables.
+const envOptOut = "-"
+
 const (
 	TagShort TagType = iota
 	TagLong
 	TagDefault
 	TagHelp
 	TagCommand
+	TagEnv
+	TagSubcommand
+	TagPersistent
+	TagCount
+	TagNegatable
+	TagNargs
+	TagSep
+	TagUnits
+	TagLayout
 )
 
+// defaultSeparator splits slice values if `sep` is given without a value.
+const defaultSeparator = ","
+
+// nargsRangeSeparator separates the minimum and maximum of a `nargs` range (iE `nargs=1..3`).
+const nargsRangeSeparator = ".."
+
 func GetTagType(tag string) (TagType, error) {
 	switch tag {
 	case "short":
@@ -27,6 +46,24 @@ func GetTagType(tag string) (TagType, error) {
 		return TagHelp, nil
 	case "command":
 		return TagCommand, nil
+	case "env":
+		return TagEnv, nil
+	case "subcommand":
+		return TagSubcommand, nil
+	case "persistent":
+		return TagPersistent, nil
+	case "count":
+		return TagCount, nil
+	case "negatable":
+		return TagNegatable, nil
+	case "nargs":
+		return TagNargs, nil
+	case "sep":
+		return TagSep, nil
+	case "units":
+		return TagUnits, nil
+	case "layout":
+		return TagLayout, nil
 	default:
 		return 0, NewUnknownTagTypeError(tag)
 	}
@@ -36,12 +73,14 @@ func GetTagType(tag string) (TagType, error) {
 type Tag struct {
 	// Type of the tag.
 	Type TagType
-	// Name gets derived from the struct field name if the tag is Short or Long and is pure computational.
+	// Name gets derived from the struct field name if the tag is Short, Long, Env or Subcommand and is pure computational.
 	Name string
 	// Value is an optional value given to the tag if an assignment operator is given. `short=s`
 	Value string
 	// Index of the tag found in the tag line.
 	Index int
+	// Column is the 1-based position of the tag within the tag line or 0 if unknown.
+	Column int
 }
 
 func NewTag(tag string, fieldName string, fieldIndex int) (*Tag, error) {
@@ -50,7 +89,20 @@ func NewTag(tag string, fieldName string, fieldIndex int) (*Tag, error) {
 	if len(parts) == 2 {
 		value = parts[1]
 	}
-	tagType, err := GetTagType(parts[0])
+	return newTag(parts[0], value, fieldName, fieldIndex)
+}
+
+// newTagFromItem creates a Tag from an item of a lexed tag line.
+func newTagFromItem(item tagItem, fieldName string, fieldIndex int) (*Tag, error) {
+	tag, err := newTag(item.Key, item.Value, fieldName, fieldIndex)
+	if tag != nil {
+		tag.Column = item.Column
+	}
+	return tag, err
+}
+
+func newTag(key string, value string, fieldName string, fieldIndex int) (*Tag, error) {
+	tagType, err := GetTagType(key)
 	if err != nil {
 		return nil, err
 	}
@@ -60,23 +112,33 @@ func NewTag(tag string, fieldName string, fieldIndex int) (*Tag, error) {
 		Index: fieldIndex,
 	}
 
-	if tagType == TagShort || tagType == TagLong {
+	if result.isNamed() {
 		result.Name = result.DeriveName(fieldName)
 	}
 
 	return result, result.Validate()
 }
 
-// ArgumentName returns the name of command line argument for this tag.
-// Overrides like `long=foo-bar` are handled here.
+// ArgumentName returns the name of command line argument (environment variable or subcommand) for this tag.
+// Overrides like `long=foo-bar` or `env=FOO_BAR` are handled here.
 func (t *Tag) ArgumentName() string {
 	// If overrides like long=foo-bar exist, then use the overriden name.
-	if t.HasValue() && (t.Type == TagShort || t.Type == TagLong) {
+	if t.HasValue() && t.isNamed() {
 		return t.Value
 	}
 	return t.Name
 }
 
+// isNamed returns true if the tag represents a name which can be derived from the field name.
+func (t *Tag) isNamed() bool {
+	switch t.Type {
+	case TagShort, TagLong, TagEnv, TagSubcommand:
+		return true
+	default:
+		return false
+	}
+}
+
 func (t *Tag) validateShort() error {
 	if len(t.Name) > 1 || len(t.Value) > 1 {
 		return ErrShortOverrideCanOnlyBeOneLetter
@@ -98,6 +160,46 @@ func (t *Tag) validateCommand() error {
 	return nil
 }
 
+func (t *Tag) validatePersistent() error {
+	if len(t.Value) > 0 {
+		return ErrPersistentCanNotHaveValue
+	}
+	return nil
+}
+
+func (t *Tag) validateCount() error {
+	if len(t.Value) > 0 {
+		return ErrCountCanNotHaveValue
+	}
+	return nil
+}
+
+func (t *Tag) validateNegatable() error {
+	if len(t.Value) > 0 {
+		return ErrNegatableCanNotHaveValue
+	}
+	return nil
+}
+
+func (t *Tag) validateNargs() error {
+	_, err := t.Nargs()
+	return err
+}
+
+func (t *Tag) validateEnv() error {
+	if strings.ContainsAny(t.Value, "= ") {
+		return ErrInvalidEnvName
+	}
+	return nil
+}
+
+func (t *Tag) validateSubcommand() error {
+	if strings.HasPrefix(t.Value, "-") {
+		return ErrSubcommandNameIsFlag
+	}
+	return nil
+}
+
 func (t *Tag) validateDefault() error {
 	if len(t.Value) == 0 {
 		return ErrNoDefaultValue
@@ -105,6 +207,13 @@ func (t *Tag) validateDefault() error {
 	return nil
 }
 
+func (t *Tag) validateLayout() error {
+	if len(t.Value) == 0 {
+		return ErrNoLayoutValue
+	}
+	return nil
+}
+
 func (t *Tag) Validate() error {
 	switch t.Type {
 	case TagShort:
@@ -117,6 +226,24 @@ func (t *Tag) Validate() error {
 		// No validation for help tags to not introduce breaking changes ATM.
 	case TagCommand:
 		return t.validateCommand()
+	case TagEnv:
+		return t.validateEnv()
+	case TagSubcommand:
+		return t.validateSubcommand()
+	case TagPersistent:
+		return t.validatePersistent()
+	case TagCount:
+		return t.validateCount()
+	case TagNegatable:
+		return t.validateNegatable()
+	case TagNargs:
+		return t.validateNargs()
+	case TagSep:
+		// Any separator is fine, an empty one means the default.
+	case TagUnits:
+		// Any unit symbol is fine, an empty one means numbers with prefixes only.
+	case TagLayout:
+		return t.validateLayout()
 	default:
 		return NewUnknownTagTypeError(t.Name)
 	}
@@ -124,6 +251,26 @@ func (t *Tag) Validate() error {
 	return nil
 }
 
+// Nargs bounds the number of values a single occurrence of a slice flag takes.
+type Nargs struct {
+	Min int
+	Max int
+}
+
+// Nargs parses the value of a `nargs` tag, which is either a count (iE `nargs=2`) or a range (iE `nargs=1..3`).
+func (t *Tag) Nargs() (Nargs, error) {
+	minimum, maximum, isRange := strings.Cut(t.Value, nargsRangeSeparator)
+	if !isRange {
+		maximum = minimum
+	}
+	lower, errMin := strconv.Atoi(minimum)
+	upper, errMax := strconv.Atoi(maximum)
+	if errMin != nil || errMax != nil || lower < 1 || upper < lower {
+		return Nargs{}, ErrInvalidNargs
+	}
+	return Nargs{Min: lower, Max: upper}, nil
+}
+
 func (t *Tag) HasValue() bool {
 	return t.Value != ""
 }
@@ -160,7 +307,26 @@ func deriveLongName(fieldName string) string {
 	return name
 }
 
+// deriveEnvName returns the upper snake case variant of the long name (iE FooBar -> FOO_BAR).
+func deriveEnvName(fieldName string) string {
+	return strings.ToUpper(strings.ReplaceAll(deriveLongName(fieldName), "-", "_"))
+}
+
 func (t *Tag) DeriveName(fieldName string) string {
+	if t.Type == TagEnv {
+		if t.HasValue() {
+			return t.Value
+		}
+		return deriveEnvName(fieldName)
+	}
+
+	if t.Type == TagSubcommand {
+		if t.HasValue() {
+			return t.Value
+		}
+		return deriveLongName(fieldName)
+	}
+
 	if t.HasValue() {
 		if t.Type == TagShort {
 			return t.Value[:1]
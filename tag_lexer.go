@@ -0,0 +1,100 @@
+package clapper
+
+import "strings"
+
+const (
+	// tagSeparator separates the items of a tag line.
+	tagSeparator = ','
+	// tagAssignment separates the key of a tag item from its value.
+	tagAssignment = '='
+	// tagEscape makes the following character part of a value instead of ending it (iE `default=a\,b`).
+	// Other escaped characters are kept as they are, including the escape.
+	tagEscape = '\\'
+	// tagQuotes are the characters which can quote a value.
+	tagQuotes = `'"`
+)
+
+// tagItem is a single `key` or `key=value` item of a tag line.
+type tagItem struct {
+	Key   string
+	Value string
+	// Column is the 1-based position of the item within the tag line.
+	Column int
+}
+
+// lexTagLine splits a tag line into its items. Values may be quoted with single or double quotes, which allows
+// commas inside of them (iE `help='Host, port and path'`). Quotes are only recognized at the start of a value, so
+// `help=Don't` keeps its apostrophe. On error, the 1-based column of the offending character is returned.
+func lexTagLine(tagLine string) (items []tagItem, column int, err error) {
+	runes := []rune(tagLine)
+	items = make([]tagItem, 0)
+	pos := 0
+	for {
+		item := tagItem{Column: pos + 1}
+		start := pos
+		for pos < len(runes) && runes[pos] != tagSeparator && runes[pos] != tagAssignment {
+			pos++
+		}
+		item.Key = string(runes[start:pos])
+
+		if pos < len(runes) && runes[pos] == tagAssignment {
+			if item.Value, pos, err = lexTagValue(runes, pos+1); err != nil {
+				return nil, pos + 1, err
+			}
+		}
+
+		items = append(items, item)
+		if pos >= len(runes) {
+			return items, 0, nil
+		}
+		// Skip the separator.
+		pos++
+	}
+}
+
+// lexTagValue reads the value starting at `pos` up to the next separator or the end of the tag line.
+// It returns the unquoted and unescaped value and the position following it. On error, it returns the position of
+// the offending character instead.
+func lexTagValue(runes []rune, pos int) (value string, next int, err error) {
+	if pos < len(runes) && strings.ContainsRune(tagQuotes, runes[pos]) {
+		return lexQuotedTagValue(runes, pos)
+	}
+
+	var result strings.Builder
+	for ; pos < len(runes) && runes[pos] != tagSeparator; pos++ {
+		if runes[pos] == tagEscape && pos+1 < len(runes) {
+			pos++
+			if runes[pos] != tagSeparator && runes[pos] != tagEscape && !strings.ContainsRune(tagQuotes, runes[pos]) {
+				result.WriteRune(tagEscape)
+			}
+		}
+		result.WriteRune(runes[pos])
+	}
+	return result.String(), pos, nil
+}
+
+// lexQuotedTagValue reads the value quoted by the quote at `pos`, which has to be followed by a separator or the end
+// of the tag line.
+func lexQuotedTagValue(runes []rune, pos int) (value string, next int, err error) {
+	quote := runes[pos]
+	var result strings.Builder
+	for current := pos + 1; current < len(runes); current++ {
+		switch runes[current] {
+		case quote:
+			current++
+			if current < len(runes) && runes[current] != tagSeparator {
+				return "", current, ErrUnexpectedAfterQuote
+			}
+			return result.String(), current, nil
+		case tagEscape:
+			if current+1 < len(runes) {
+				current++
+				if runes[current] != quote && runes[current] != tagEscape {
+					result.WriteRune(tagEscape)
+				}
+			}
+		}
+		result.WriteRune(runes[current])
+	}
+	return "", pos, ErrUnterminatedQuote
+}
@@ -0,0 +1,99 @@
+package clapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLexTagLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []tagItem
+	}{
+		{
+			name:     "single tag",
+			input:    "long",
+			expected: []tagItem{{Key: "long", Column: 1}},
+		},
+		{
+			name:     "multiple tags",
+			input:    "short,long=foo",
+			expected: []tagItem{{Key: "short", Column: 1}, {Key: "long", Value: "foo", Column: 7}},
+		},
+		{
+			name:     "single quotes",
+			input:    "help='Host, port and path',long",
+			expected: []tagItem{{Key: "help", Value: "Host, port and path", Column: 1}, {Key: "long", Column: 28}},
+		},
+		{
+			name:     "double quotes",
+			input:    `default="localhost:8080"`,
+			expected: []tagItem{{Key: "default", Value: "localhost:8080", Column: 1}},
+		},
+		{
+			name:     "escaped quotes",
+			input:    `help='It\'s \\ fine',help="say \"hi\""`,
+			expected: []tagItem{{Key: "help", Value: `It's \ fine`, Column: 1}, {Key: "help", Value: `say "hi"`, Column: 22}},
+		},
+		{
+			name:     "other quotes within quotes",
+			input:    `help="It's fine"`,
+			expected: []tagItem{{Key: "help", Value: "It's fine", Column: 1}},
+		},
+		{
+			name:     "apostrophe in unquoted value",
+			input:    "help=Don't do it",
+			expected: []tagItem{{Key: "help", Value: "Don't do it", Column: 1}},
+		},
+		{
+			name:     "escaped comma",
+			input:    `long,sep=\,,default=a\,b`,
+			expected: []tagItem{{Key: "long", Column: 1}, {Key: "sep", Value: ",", Column: 6}, {Key: "default", Value: "a,b", Column: 13}},
+		},
+		{
+			name:     "other escapes are kept",
+			input:    `help=a\b\`,
+			expected: []tagItem{{Key: "help", Value: `a\b\`, Column: 1}},
+		},
+		{
+			name:     "empty quoted value",
+			input:    "default='',long",
+			expected: []tagItem{{Key: "default", Column: 1}, {Key: "long", Column: 12}},
+		},
+		{
+			name:     "empty tag",
+			input:    "long,",
+			expected: []tagItem{{Key: "long", Column: 1}, {Key: "", Column: 6}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := lexTagLine(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestLexTagLineErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		column int
+		err    error
+	}{
+		{name: "unterminated quote", input: "long,help='Display help", column: 11, err: ErrUnterminatedQuote},
+		{name: "escaped closing quote", input: `help='Display\'`, column: 6, err: ErrUnterminatedQuote},
+		{name: "text after quote", input: "help='Display' help,long", column: 15, err: ErrUnexpectedAfterQuote},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, column, err := lexTagLine(tt.input)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.column, column)
+		})
+	}
+}
@@ -1,5 +1,8 @@
 package clapper
 
+// negationPrefix is prepended to the long name of a `negatable` bool to turn it off (iE `--no-color`).
+const negationPrefix = "no-"
+
 type (
 	// TagMap represents all tags in a single struct fields tag line.
 	TagMap map[TagType]Tag
@@ -19,6 +22,76 @@ func (t TagMap) HasInputTag() bool {
 	return t.HasTagType(TagShort) || t.HasTagType(TagLong)
 }
 
+// IsSubcommand returns true if the TagMap describes a nested command struct.
+func (t TagMap) IsSubcommand() bool {
+	return t.HasTagType(TagSubcommand)
+}
+
+// SubcommandName returns the name of the subcommand or an empty string if the TagMap is no subcommand.
+func (t TagMap) SubcommandName() string {
+	tag, ok := t[TagSubcommand]
+	if !ok {
+		return ""
+	}
+	return tag.ArgumentName()
+}
+
+// NegationName returns the name of the long flag turning a `negatable` bool off (iE `no-color`).
+func (t TagMap) NegationName() (string, bool) {
+	tag, ok := t[TagLong]
+	if !ok || !t.HasTagType(TagNegatable) {
+		return "", false
+	}
+	return negationPrefix + tag.ArgumentName(), true
+}
+
+// Nargs returns the bounds of the `nargs` tag if there is one.
+func (t TagMap) Nargs() (Nargs, bool) {
+	tag, ok := t[TagNargs]
+	if !ok {
+		return Nargs{}, false
+	}
+	// Tags are validated when parsed, so the value is well-formed.
+	nargs, err := tag.Nargs()
+	return nargs, err == nil
+}
+
+// Separator returns the separator splitting slice values if there is a `sep` tag.
+func (t TagMap) Separator() (string, bool) {
+	tag, ok := t[TagSep]
+	if !ok {
+		return "", false
+	}
+	if !tag.HasValue() {
+		return defaultSeparator, true
+	}
+	return tag.Value, true
+}
+
+// Units returns the unit symbol of the `units` tag, which is empty for a bare `units`, and whether there is one.
+func (t TagMap) Units() (string, bool) {
+	tag, ok := t[TagUnits]
+	return tag.Value, ok
+}
+
+// Layout returns the value of the `layout` tag or an empty string for the default layout.
+func (t TagMap) Layout() string {
+	return t[TagLayout].Value
+}
+
+// FieldIndex returns the index of the struct field the tags belong to or -1 if there are no tags.
+func (t TagMap) FieldIndex() int {
+	for _, tag := range t {
+		return tag.Index
+	}
+	return -1
+}
+
+// Column returns the 1-based position of the tag within the tag line or 0 if there is no such tag.
+func (t TagMap) Column(tagType TagType) int {
+	return t[tagType].Column
+}
+
 // InputArgument returns the name of the command line argument.
 // Long names take precedence over short names.
 // If there is no input tag, it returns "<unknown>".
@@ -2,7 +2,6 @@ package clapper
 
 import (
 	"reflect"
-	"strings"
 )
 
 const (
@@ -16,32 +15,75 @@ type (
 )
 
 // parseTags parses the tags for a given field (aka "one line") and returns them as a map.
-func parseTags(tagItems []string, fieldName string, index int) (TagMap, error) {
+// Errors are reported as ParseError with the column of the responsible tag.
+func parseTags(tagItems []tagItem, fieldName string, index int, tagLine string) (TagMap, error) {
 	tags := make(map[TagType]Tag, 0)
 	for _, tagItem := range tagItems {
-		tag, err := NewTag(tagItem, fieldName, index)
+		tag, err := newTagFromItem(tagItem, fieldName, index)
 		if err != nil {
-			return nil, err
+			return nil, NewParseErrorAt(err, index, fieldName, tagLine, tagItem.Column)
 		}
 		tags[tag.Type] = *tag
 	}
 	return tags, nil
 }
 
+// validateSubcommand checks that a field tagged as `subcommand` can hold a nested command struct.
+func validateSubcommand(field reflect.StructField, tags TagMap) error {
+	for tagType := range tags {
+		if tagType != TagSubcommand && tagType != TagHelp {
+			return ErrSubcommandWithInput
+		}
+	}
+	if field.Type.Kind() != reflect.Pointer || field.Type.Elem().Kind() != reflect.Struct {
+		return ErrSubcommandNoStructPointer
+	}
+	return nil
+}
+
+// validateCombinations checks that the tags of a field fit to each other and to the field's type.
+// On error, the column of the tag requiring something is returned.
+func validateCombinations(field reflect.StructField, tags TagMap, conv conversion) (column int, err error) {
+	checks := []struct {
+		tagType TagType
+		valid   bool
+		err     error
+	}{
+		{TagPersistent, tags.HasInputTag(), ErrPersistentWithoutFlag},
+		{TagCount, tags.HasInputTag() && isIntegerType(field.Type), ErrCountNoInteger},
+		{TagNegatable, tags.HasTagType(TagLong) && isBoolType(field.Type), ErrNegatableNoBool},
+		{TagNargs, tags.HasInputTag() && conv.isMultiValueType(field.Type), ErrNargsNoSlice},
+		{TagSep, conv.isMultiValueType(field.Type), ErrSepNoSlice},
+		{TagUnits, isIntegerType(field.Type) || conv.isMultiValueType(field.Type) && isIntegerType(field.Type.Elem()), ErrUnitsNoInteger},
+		{TagLayout, conv.isTimeType(field.Type), ErrLayoutNoTime},
+	}
+	for _, check := range checks {
+		if tags.HasTagType(check.tagType) && !check.valid {
+			return tags.Column(check.tagType), check.err
+		}
+	}
+	return 0, nil
+}
+
 // parseStructTags parses a given struct and returns all of its parsed tags.
-func parseStructTags(t reflect.Type) (ParsedTags, error) {
+// The types converted as a whole are determined by `conv`.
+func parseStructTags(t reflect.Type, conv conversion) (ParsedTags, error) {
 	parsedTags := make(map[int]TagMap, 0)
 	commandTagSpecified := false
+	subcommands := make(map[string]bool)
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
 		tagLine := field.Tag.Get(TagName)
 		if tagLine == "" {
 			continue
 		}
-		tagItems := strings.Split(tagLine, ",")
-		tags, err := parseTags(tagItems, field.Name, i)
+		tagItems, column, err := lexTagLine(tagLine)
 		if err != nil {
-			return nil, NewParseError(err, i, field.Name, tagLine)
+			return nil, NewParseErrorAt(err, i, field.Name, tagLine, column)
+		}
+		tags, err := parseTags(tagItems, field.Name, i, tagLine)
+		if err != nil {
+			return nil, err
 		}
 		if tags.HasTagType(TagCommand) {
 			if commandTagSpecified {
@@ -49,7 +91,23 @@ func parseStructTags(t reflect.Type) (ParsedTags, error) {
 			}
 			commandTagSpecified = true
 		}
+		if column, err = validateCombinations(field, tags, conv); err != nil {
+			return nil, NewParseErrorAt(err, i, field.Name, tagLine, column)
+		}
+		if tags.IsSubcommand() {
+			if err = validateSubcommand(field, tags); err != nil {
+				return nil, NewParseErrorAt(err, i, field.Name, tagLine, tags.Column(TagSubcommand))
+			}
+			name := tags.SubcommandName()
+			if subcommands[name] {
+				return nil, ErrDuplicateSubcommand
+			}
+			subcommands[name] = true
+		}
 		parsedTags[i] = tags
 	}
+	if commandTagSpecified && len(subcommands) > 0 {
+		return nil, ErrCommandWithSubcommands
+	}
 	return parsedTags, nil
 }
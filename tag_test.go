@@ -30,6 +30,25 @@ func TestDeriveLongName(t *testing.T) {
 	}
 }
 
+func TestDeriveEnvName(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "single word", input: "Host", expected: "HOST"},
+		{name: "mixed case", input: "DbHost", expected: "DB_HOST"},
+		{name: "acronym inside", input: "ExternalTCPSocket", expected: "EXTERNAL_TCP_SOCKET"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deriveEnvName(tt.input); got != tt.expected {
+				t.Errorf("deriveEnvName() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestValidation(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -44,6 +63,9 @@ func TestValidation(t *testing.T) {
 		{name: "command tag without value is ok", tag: Tag{Type: TagCommand, Name: "", Value: ""}, wantErr: false},
 		{name: "default tag with value is ok", tag: Tag{Type: TagDefault, Name: "", Value: "some"}, wantErr: false},
 		{name: "default tag without value fails", tag: Tag{Type: TagDefault, Name: "", Value: ""}, wantErr: true},
+		{name: "env tag without value is ok", tag: Tag{Type: TagEnv, Name: "FOO", Value: ""}, wantErr: false},
+		{name: "env tag with value is ok", tag: Tag{Type: TagEnv, Name: "FOO", Value: "FOO"}, wantErr: false},
+		{name: "env tag with assignment in value fails", tag: Tag{Type: TagEnv, Name: "FOO", Value: "FOO=BAR"}, wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -65,6 +87,7 @@ func TestTagType(t *testing.T) {
 		{tagName: "default", wantTagType: TagDefault, wantErr: false},
 		{tagName: "help", wantTagType: TagHelp, wantErr: false},
 		{tagName: "command", wantTagType: TagCommand, wantErr: false},
+		{tagName: "env", wantTagType: TagEnv, wantErr: false},
 		{tagName: "unknown", wantTagType: 0, wantErr: true},
 		{tagName: "SHORT", wantTagType: 0, wantErr: true},
 	}